@@ -18,6 +18,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/klog"
@@ -30,6 +31,9 @@ var onByDefault bool
 var includeNamespaces []string
 var excludeNamespaces []string
 var dryRun bool
+var annotateWorkloads bool
+var annotationDebounce time.Duration
+var annotationChangeThreshold float64
 
 func init() {
 	rootCmd.AddCommand(controllerCmd)
@@ -37,6 +41,9 @@ func init() {
 	controllerCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "", false, "If true, don't mutate resources, just list what would have been created.")
 	controllerCmd.PersistentFlags().StringArrayVarP(&includeNamespaces, "include-namespaces", "", []string{}, "Comma delimited list of namespaces to include from recommendations.")
 	controllerCmd.PersistentFlags().StringArrayVarP(&excludeNamespaces, "exclude-namespaces", "", []string{}, "Comma delimited list of namespaces to exclude from recommendations.")
+	controllerCmd.PersistentFlags().BoolVarP(&annotateWorkloads, "annotate-workloads", "", false, "Write the current recommendations as an annotation on each workload.")
+	controllerCmd.PersistentFlags().DurationVarP(&annotationDebounce, "annotation-debounce", "", 30*time.Second, "How long a VPA must go without further changes before its workload is annotated, which restarts with each change.")
+	controllerCmd.PersistentFlags().Float64VarP(&annotationChangeThreshold, "annotation-change-threshold", "", 0.1, "Fraction a recommendation must change by before the workload annotation is rewritten.")
 }
 
 var controllerCmd = &cobra.Command{
@@ -48,6 +55,9 @@ var controllerCmd = &cobra.Command{
 		vpaReconciler.OnByDefault = onByDefault
 		vpaReconciler.IncludeNamespaces = includeNamespaces
 		vpaReconciler.ExcludeNamespaces = excludeNamespaces
+		vpaReconciler.AnnotateWorkloads = annotateWorkloads
+		vpaReconciler.AnnotationDebounce = annotationDebounce
+		vpaReconciler.AnnotationChangeThreshold = annotationChangeThreshold
 
 		klog.V(4).Infof("Starting controller with Reconciler: %+v", vpaReconciler)
 
@@ -0,0 +1,87 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+)
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+	coverageCmd.PersistentFlags().StringVarP(&outputFile, "output-file", "f", "", "File to write the coverage report to.")
+	coverageCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Limit the coverage report to only a single Namespace.")
+	coverageCmd.PersistentFlags().StringSliceVar(&fromFiles, "from-files", nil, "Comma delimited list of YAML or JSON manifest files and directories, such as a cluster dump, to report on instead of the cluster.")
+	coverageCmd.PersistentFlags().BoolVarP(&onByDefault, "on-by-default", "", false, "Report on the namespaces of a controller that adds goldilocks to every namespace that isn't explicitly excluded.")
+	coverageCmd.PersistentFlags().StringArrayVarP(&includeNamespaces, "include-namespaces", "", []string{}, "Comma delimited list of namespaces the controller includes in recommendations.")
+	coverageCmd.PersistentFlags().StringArrayVarP(&excludeNamespaces, "exclude-namespaces", "", []string{}, "Comma delimited list of namespaces the controller excludes from recommendations.")
+	coverageCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summary.OutputTable, fmt.Sprintf("Output format, one of: %s.", strings.Join(summary.CoverageOutputFormats, "|")))
+}
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report which workloads have goldilocks VPAs.",
+	Long: `List every workload and whether goldilocks manages a VPA for it, with the reason for those it does not manage.
+Pass the same --on-by-default, --include-namespaces and --exclude-namespaces flags as the controller.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := []summary.Option{
+			summary.ForManagedNamespaces(onByDefault, includeNamespaces, excludeNamespaces),
+		}
+
+		// limit to a single namespace
+		if namespace != "" {
+			opts = append(opts, summary.ForNamespace(namespace))
+		}
+
+		// report on manifests instead of the cluster
+		if len(fromFiles) > 0 {
+			opts = append(opts, summary.FromFiles(fromFiles...))
+		}
+
+		summarizer := summary.NewSummarizer(opts...)
+		data, err := summarizer.GetCoverage()
+		if err != nil {
+			klog.Fatalf("Error getting coverage: %v", err)
+		}
+
+		output := &bytes.Buffer{}
+		if err := summary.WriteCoverage(output, data, outputFormat); err != nil {
+			klog.Fatalf("Error writing coverage: %v", err)
+		}
+
+		if outputFile != "" {
+			err := ioutil.WriteFile(outputFile, output.Bytes(), 0644)
+			if err != nil {
+				klog.Fatalf("Failed to write coverage to file: %v", err)
+			}
+
+			fmt.Println("Coverage has been written to", outputFile)
+
+		} else {
+			_, err := output.WriteTo(os.Stdout)
+			if err != nil {
+				klog.Fatalf("Failed to write coverage: %v", err)
+			}
+		}
+	},
+}
@@ -15,25 +15,82 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 
 	"github.com/fairwindsops/goldilocks/pkg/dashboard"
+	"github.com/fairwindsops/goldilocks/pkg/dashboard/auth"
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
 )
 
 var serverPort int
 var basePath string
+var refreshInterval time.Duration
+var cacheSyncTimeout time.Duration
+var authTokenFile string
+var authProxyUserHeader string
+var authProxyGroupsHeader string
+var authProxyTrustedCIDRs []string
+var oidcIssuerURL string
+var oidcClientID string
+var oidcClientSecret string
+var oidcRedirectURL string
+var oidcScopes []string
+var oidcUsernameClaim string
+var oidcGroupsClaim string
 
 func init() {
 	rootCmd.AddCommand(dashboardCmd)
 	dashboardCmd.PersistentFlags().IntVarP(&serverPort, "port", "p", 8080, "The port to serve the dashboard on.")
 	dashboardCmd.PersistentFlags().StringVar(&basePath, "base-path", "/", "Path on which the dashboard is served")
+	dashboardCmd.PersistentFlags().DurationVar(&refreshInterval, "refresh-interval", time.Minute, "How long to serve a summary before refreshing it in the background, or 0 to summarize on every request.")
+	dashboardCmd.PersistentFlags().DurationVar(&cacheSyncTimeout, "cache-sync-timeout", time.Minute, "How long to wait for the objects to be cached at startup before listing them on every summary instead.")
 	dashboardCmd.PersistentFlags().StringVarP(&excludeContainers, "exclude-containers", "e", "", "Comma delimited list of containers to exclude from recommendations.")
+	dashboardCmd.PersistentFlags().StringVar(&cpuRoundingStep, "cpu-rounding-step", "", "Round suggested cpu requests and limits to a multiple of this step, e.g. 10m.")
+	dashboardCmd.PersistentFlags().StringVar(&memoryRoundingStep, "memory-rounding-step", "", "Round suggested memory requests and limits to a multiple of this step, e.g. 16Mi for binary or 10M for decimal units.")
+	dashboardCmd.PersistentFlags().StringVar(&roundingMode, "rounding-mode", utils.RoundUp, fmt.Sprintf("How to round suggested values to the rounding steps, one of: %s|%s.", utils.RoundUp, utils.RoundNearest))
+	dashboardCmd.PersistentFlags().StringVar(&recommendationPolicyFile, "recommendation-policy-file", "", "YAML file of the policy used to calculate suggested requests and limits from the recommendations.")
+	dashboardCmd.PersistentFlags().StringVar(&pricingFile, "pricing-file", "", "YAML file of unit prices used to estimate the cost of each workload and namespace.")
+	dashboardCmd.PersistentFlags().StringVar(&efficiencyFile, "efficiency-file", "", "YAML file of the resource weights and letter grades of the efficiency scores.")
+	dashboardCmd.PersistentFlags().BoolVarP(&onByDefault, "on-by-default", "", false, "Report on the namespaces of a controller that adds goldilocks to every namespace that isn't explicitly excluded.")
+	dashboardCmd.PersistentFlags().StringArrayVarP(&includeNamespaces, "include-namespaces", "", []string{}, "Comma delimited list of namespaces the controller includes in recommendations.")
+	dashboardCmd.PersistentFlags().StringArrayVarP(&excludeNamespaces, "exclude-namespaces", "", []string{}, "Comma delimited list of namespaces the controller excludes from recommendations.")
+	dashboardCmd.PersistentFlags().StringVar(&authTokenFile, "auth-token-file", "", "YAML file of static bearer tokens and their users that may use the dashboard.")
+	dashboardCmd.PersistentFlags().StringVar(&authProxyUserHeader, "auth-proxy-user-header", "", "Header with the user authenticated by an auth proxy in front of the dashboard, such as X-Forwarded-User.")
+	dashboardCmd.PersistentFlags().StringVar(&authProxyGroupsHeader, "auth-proxy-groups-header", "X-Forwarded-Groups", "Header with the comma delimited groups of the user authenticated by the auth proxy.")
+	dashboardCmd.PersistentFlags().StringSliceVar(&authProxyTrustedCIDRs, "auth-proxy-trusted-cidrs", nil, "Comma delimited list of CIDRs of the auth proxies whose headers are trusted, which is required with --auth-proxy-user-header.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcIssuerURL, "oidc-issuer-url", "", "URL of the OpenID Connect issuer that users log in with.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcClientID, "oidc-client-id", "", "Client ID of the dashboard with the OpenID Connect issuer.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcClientSecret, "oidc-client-secret", "", "Client secret of the dashboard with the OpenID Connect issuer.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcRedirectURL, "oidc-redirect-url", "", fmt.Sprintf("URL of the %s path of the dashboard, as users reach it.", auth.CallbackPath))
+	dashboardCmd.PersistentFlags().StringSliceVar(&oidcScopes, "oidc-scopes", nil, "Comma delimited list of scopes to request in addition to openid, such as email and groups.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcUsernameClaim, "oidc-username-claim", "sub", "Claim of the ID token with the name of the user.")
+	dashboardCmd.PersistentFlags().StringVar(&oidcGroupsClaim, "oidc-groups-claim", "", "Claim of the ID token with the groups of the user.")
+
+	environmentVariables := map[string]string{
+		"OIDC_CLIENT_SECRET": "oidc-client-secret",
+	}
+
+	for env, flag := range environmentVariables {
+		flag := dashboardCmd.PersistentFlags().Lookup(flag)
+		flag.Usage = fmt.Sprintf("%v [%v]", flag.Usage, env)
+		if value := os.Getenv(env); value != "" {
+			err := flag.Value.Set(value)
+			if err != nil {
+				klog.Errorf("Error setting flag %v from environment variable %s", flag.Name, env)
+			}
+		}
+	}
 }
 
 var dashboardCmd = &cobra.Command{
@@ -41,13 +98,110 @@ var dashboardCmd = &cobra.Command{
 	Short: "Run the goldilocks dashboard that will show recommendations.",
 	Long:  `Run the goldilocks dashboard that will show recommendations.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		router := dashboard.GetRouter(
+		opts := []dashboard.Option{
 			dashboard.OnPort(serverPort),
 			dashboard.WithBasePath(basePath),
+			dashboard.WithRefreshInterval(refreshInterval),
 			dashboard.ExcludeContainers(sets.NewString(strings.Split(excludeContainers, ",")...)),
-		)
+			dashboard.ForManagedNamespaces(onByDefault, includeNamespaces, excludeNamespaces),
+		}
+		if pricingFile != "" {
+			pricing, err := summary.LoadPricing(pricingFile)
+			if err != nil {
+				klog.Fatalf("Error loading pricing: %v", err)
+			}
+			opts = append(opts, dashboard.WithPricing(pricing))
+		}
+
+		rounding, err := utils.ParseRoundingPolicy(cpuRoundingStep, memoryRoundingStep, roundingMode)
+		if err != nil {
+			klog.Fatalf("Error parsing rounding policy: %v", err)
+		}
+		opts = append(opts, dashboard.WithRounding(rounding))
+
+		if recommendationPolicyFile != "" {
+			policy, err := summary.LoadRecommendationPolicy(recommendationPolicyFile)
+			if err != nil {
+				klog.Fatalf("Error loading recommendation policy: %v", err)
+			}
+			opts = append(opts, dashboard.WithRecommendationPolicy(policy))
+		}
+
+		if efficiencyFile != "" {
+			efficiency, err := summary.LoadEfficiencyPolicy(efficiencyFile)
+			if err != nil {
+				klog.Fatalf("Error loading efficiency policy: %v", err)
+			}
+			opts = append(opts, dashboard.WithEfficiency(efficiency))
+		}
+
+		authenticators, err := getAuthenticators()
+		if err != nil {
+			klog.Fatalf("Error configuring authentication: %v", err)
+		}
+		if len(authenticators) > 0 {
+			opts = append(opts, dashboard.WithAuthenticators(authenticators...))
+		} else {
+			klog.Warning("The dashboard is not authenticated, anyone who can reach it can see the resources of every namespace")
+		}
+
+		// watch the summarized objects once, rather than listing all of them for every request
+		cache := summary.NewCache(kube.GetInstance(), kube.GetVPAInstance(), 0)
+		stopCh := make(chan struct{})
+		if err := cache.Start(stopCh, cacheSyncTimeout); err != nil {
+			klog.Errorf("Error starting cache, listing the objects for every summary instead: %v", err)
+			close(stopCh)
+		} else {
+			opts = append(opts, dashboard.WithCache(cache))
+		}
+
+		router := dashboard.GetRouter(opts...)
 		http.Handle("/", router)
 		klog.Infof("Starting goldilocks dashboard server on port %d", serverPort)
 		klog.Fatalf("%v", http.ListenAndServe(fmt.Sprintf(":%d", serverPort), nil))
 	},
 }
+
+// getAuthenticators returns the authenticators of the auth flags, in the order static tokens, auth proxy headers and OIDC
+func getAuthenticators() ([]auth.Authenticator, error) {
+	authenticators := []auth.Authenticator{}
+	if authTokenFile != "" {
+		tokens, err := auth.LoadStaticTokens(authTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, tokens)
+	}
+
+	if authProxyUserHeader != "" {
+		if len(authProxyTrustedCIDRs) <= 0 {
+			return nil, fmt.Errorf("--auth-proxy-trusted-cidrs is required with --auth-proxy-user-header, so that only the proxies can set it")
+		}
+		cidrs, err := auth.ParseCIDRs(authProxyTrustedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, auth.ProxyHeaders{
+			UserHeader:   authProxyUserHeader,
+			GroupsHeader: authProxyGroupsHeader,
+			TrustedCIDRs: cidrs,
+		})
+	}
+
+	if oidcIssuerURL != "" {
+		oidc, err := auth.NewOIDC(context.TODO(), auth.OIDCConfig{
+			IssuerURL:     oidcIssuerURL,
+			ClientID:      oidcClientID,
+			ClientSecret:  oidcClientSecret,
+			RedirectURL:   oidcRedirectURL,
+			Scopes:        oidcScopes,
+			UsernameClaim: oidcUsernameClaim,
+			GroupsClaim:   oidcGroupsClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, oidc)
+	}
+	return authenticators, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+var patchFormat string
+var patchOutputDir string
+
+func init() {
+	rootCmd.AddCommand(patchesCmd)
+	patchesCmd.PersistentFlags().StringVarP(&excludeContainers, "exclude-containers", "e", "", "Comma delimited list of containers to exclude from the patches.")
+	patchesCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Limit the patches to only a single Namespace.")
+	patchesCmd.PersistentFlags().StringVar(&cpuRoundingStep, "cpu-rounding-step", "", "Round suggested cpu requests and limits to a multiple of this step, e.g. 10m.")
+	patchesCmd.PersistentFlags().StringVar(&memoryRoundingStep, "memory-rounding-step", "", "Round suggested memory requests and limits to a multiple of this step, e.g. 16Mi for binary or 10M for decimal units.")
+	patchesCmd.PersistentFlags().StringVar(&roundingMode, "rounding-mode", utils.RoundUp, fmt.Sprintf("How to round suggested values to the rounding steps, one of: %s|%s.", utils.RoundUp, utils.RoundNearest))
+	patchesCmd.PersistentFlags().StringVar(&recommendationPolicyFile, "recommendation-policy-file", "", "YAML file of the policy used to calculate suggested requests and limits from the recommendations.")
+	patchesCmd.PersistentFlags().StringVar(&qos, "qos", summary.QoSGuaranteed, fmt.Sprintf("Recommendation to set container resources to, one of: %s.", strings.Join(summary.QoSClasses, "|")))
+	patchesCmd.PersistentFlags().StringVarP(&patchFormat, "format", "o", summary.PatchStrategic, fmt.Sprintf("Patch format, one of: %s.", strings.Join(summary.PatchFormats, "|")))
+	patchesCmd.PersistentFlags().StringVarP(&patchOutputDir, "output-dir", "d", "", "Directory to write a patch file per workload to. By default the patches are written to stdout.")
+}
+
+var patchesCmd = &cobra.Command{
+	Use:   "patches",
+	Short: "Generate patches that apply vpa recommendations.",
+	Long: `Gather all the vpa data and generate a patch for each workload that sets its container resources to the chosen recommendation.
+Patches can be strategic merge patches, kustomize patches, or kubectl patch commands.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !sets.NewString(summary.PatchFormats...).Has(patchFormat) {
+			klog.Fatalf("Unsupported patch format %q, must be one of: %s", patchFormat, strings.Join(summary.PatchFormats, ", "))
+		}
+
+		var opts []summary.Option
+
+		// limit to a single namespace
+		if namespace != "" {
+			opts = append(opts, summary.ForNamespace(namespace))
+		}
+
+		// exclude containers from the patches
+		if excludeContainers != "" {
+			opts = append(opts, summary.ExcludeContainers(sets.NewString(strings.Split(excludeContainers, ",")...)))
+		}
+
+		// round suggested values
+		rounding, err := utils.ParseRoundingPolicy(cpuRoundingStep, memoryRoundingStep, roundingMode)
+		if err != nil {
+			klog.Fatalf("Error parsing rounding policy: %v", err)
+		}
+		opts = append(opts, summary.WithRounding(rounding))
+
+		// suggest requests and limits with a recommendation policy
+		if recommendationPolicyFile != "" {
+			policy, err := summary.LoadRecommendationPolicy(recommendationPolicyFile)
+			if err != nil {
+				klog.Fatalf("Error loading recommendation policy: %v", err)
+			}
+			opts = append(opts, summary.WithRecommendationPolicy(policy))
+		}
+
+		summarizer := summary.NewSummarizer(opts...)
+		data, err := summarizer.GetSummary()
+		if err != nil {
+			klog.Fatalf("Error getting summary: %v", err)
+		}
+
+		patches, err := summary.Patches(data, qos)
+		if err != nil {
+			klog.Fatalf("Error generating patches: %v", err)
+		}
+
+		if patchOutputDir != "" {
+			if err := os.MkdirAll(patchOutputDir, 0755); err != nil {
+				klog.Fatalf("Failed to create output directory: %v", err)
+			}
+			for _, patch := range patches {
+				output := &bytes.Buffer{}
+				if err := patch.Write(output, patchFormat); err != nil {
+					klog.Fatalf("Error writing patch: %v", err)
+				}
+				path := filepath.Join(patchOutputDir, patch.FileName(patchFormat))
+				if err := ioutil.WriteFile(path, output.Bytes(), 0644); err != nil {
+					klog.Fatalf("Failed to write patch to file: %v", err)
+				}
+			}
+
+			fmt.Printf("%d patches have been written to %s\n", len(patches), patchOutputDir)
+			return
+		}
+
+		for i, patch := range patches {
+			if i > 0 && patchFormat != summary.PatchKubectl {
+				fmt.Println("---")
+			}
+			if err := patch.Write(os.Stdout, patchFormat); err != nil {
+				klog.Fatalf("Error writing patch: %v", err)
+			}
+		}
+	},
+}
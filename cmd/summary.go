@@ -15,9 +15,10 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -25,17 +26,40 @@ import (
 	"k8s.io/klog"
 
 	"github.com/fairwindsops/goldilocks/pkg/summary"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
 )
 
 var excludeContainers string
 var outputFile string
 var namespace string
+var outputFormat string
+var pricingFile string
+var cpuRoundingStep string
+var memoryRoundingStep string
+var roundingMode string
+var recommendationPolicyFile string
+var qos string
+var helmValuesPath string
+var fromFiles []string
+var pageSize int64
+var efficiencyFile string
 
 func init() {
 	rootCmd.AddCommand(summaryCmd)
 	summaryCmd.PersistentFlags().StringVarP(&excludeContainers, "exclude-containers", "e", "", "Comma delimited list of containers to exclude from recommendations.")
 	summaryCmd.PersistentFlags().StringVarP(&outputFile, "output-file", "f", "", "File to write output from audit.")
 	summaryCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Limit the summary to only a single Namespace.")
+	summaryCmd.PersistentFlags().StringSliceVar(&fromFiles, "from-files", nil, "Comma delimited list of YAML or JSON manifest files and directories, such as a cluster dump, to summarize instead of the cluster.")
+	summaryCmd.PersistentFlags().Int64Var(&pageSize, "page-size", 500, "Number of VPAs and Deployments to list from the cluster at a time.")
+	summaryCmd.PersistentFlags().StringVar(&pricingFile, "pricing-file", "", "YAML file of unit prices used to estimate the cost of each workload and namespace.")
+	summaryCmd.PersistentFlags().StringVar(&cpuRoundingStep, "cpu-rounding-step", "", "Round suggested cpu requests and limits to a multiple of this step, e.g. 10m.")
+	summaryCmd.PersistentFlags().StringVar(&memoryRoundingStep, "memory-rounding-step", "", "Round suggested memory requests and limits to a multiple of this step, e.g. 16Mi for binary or 10M for decimal units.")
+	summaryCmd.PersistentFlags().StringVar(&roundingMode, "rounding-mode", utils.RoundUp, fmt.Sprintf("How to round suggested values to the rounding steps, one of: %s|%s.", utils.RoundUp, utils.RoundNearest))
+	summaryCmd.PersistentFlags().StringVar(&recommendationPolicyFile, "recommendation-policy-file", "", "YAML file of the policy used to calculate suggested requests and limits from the recommendations.")
+	summaryCmd.PersistentFlags().StringVar(&efficiencyFile, "efficiency-file", "", "YAML file of the resource weights and letter grades of the efficiency scores.")
+	summaryCmd.PersistentFlags().StringVar(&qos, "qos", summary.QoSGuaranteed, fmt.Sprintf("Recommendation to set container resources to in the helm output, one of: %s.", strings.Join(summary.QoSClasses, "|")))
+	summaryCmd.PersistentFlags().StringVar(&helmValuesPath, "helm-values-path", summary.DefaultHelmValuesPath, "Path of container resources in the values of the helm output, which may contain the {{container}}, {{deployment}} and {{release}} placeholders.")
+	summaryCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summary.OutputJSON, fmt.Sprintf("Output format, one of: %s.", strings.Join(summary.OutputFormats, "|")))
 }
 
 var summaryCmd = &cobra.Command{
@@ -52,24 +76,71 @@ By default the summary will be about all VPAs in all namespaces.`,
 			opts = append(opts, summary.ForNamespace(namespace))
 		}
 
+		// summarize manifests instead of the cluster
+		if len(fromFiles) > 0 {
+			opts = append(opts, summary.FromFiles(fromFiles...))
+		}
+
+		// list the cluster a page at a time
+		opts = append(opts, summary.WithPageSize(pageSize))
+
 		// exclude containers from the summary
 		if excludeContainers != "" {
 			opts = append(opts, summary.ExcludeContainers(sets.NewString(strings.Split(excludeContainers, ",")...)))
 		}
 
+		// estimate costs
+		if pricingFile != "" {
+			pricing, err := summary.LoadPricing(pricingFile)
+			if err != nil {
+				klog.Fatalf("Error loading pricing: %v", err)
+			}
+			opts = append(opts, summary.WithPricing(pricing))
+		}
+
+		// round suggested values
+		rounding, err := utils.ParseRoundingPolicy(cpuRoundingStep, memoryRoundingStep, roundingMode)
+		if err != nil {
+			klog.Fatalf("Error parsing rounding policy: %v", err)
+		}
+		opts = append(opts, summary.WithRounding(rounding))
+
+		// suggest requests and limits with a recommendation policy
+		if recommendationPolicyFile != "" {
+			policy, err := summary.LoadRecommendationPolicy(recommendationPolicyFile)
+			if err != nil {
+				klog.Fatalf("Error loading recommendation policy: %v", err)
+			}
+			opts = append(opts, summary.WithRecommendationPolicy(policy))
+		}
+
+		// weigh and grade efficiency scores
+		if efficiencyFile != "" {
+			efficiency, err := summary.LoadEfficiencyPolicy(efficiencyFile)
+			if err != nil {
+				klog.Fatalf("Error loading efficiency policy: %v", err)
+			}
+			opts = append(opts, summary.WithEfficiency(efficiency))
+		}
+
 		summarizer := summary.NewSummarizer(opts...)
 		data, err := summarizer.GetSummary()
 		if err != nil {
 			klog.Fatalf("Error getting summary: %v", err)
 		}
 
-		summaryJSON, err := json.Marshal(data)
+		output := &bytes.Buffer{}
+		if outputFormat == summary.OutputHelm {
+			err = summary.WriteHelmValues(output, data, helmValuesPath, qos)
+		} else {
+			err = summary.Write(output, data, outputFormat)
+		}
 		if err != nil {
-			klog.Fatalf("Error marshalling JSON: %v", err)
+			klog.Fatalf("Error writing summary: %v", err)
 		}
 
 		if outputFile != "" {
-			err := ioutil.WriteFile(outputFile, summaryJSON, 0644)
+			err := ioutil.WriteFile(outputFile, output.Bytes(), 0644)
 			if err != nil {
 				klog.Fatalf("Failed to write summary to file: %v", err)
 			}
@@ -77,7 +148,10 @@ By default the summary will be about all VPAs in all namespaces.`,
 			fmt.Println("Summary has been written to", outputFile)
 
 		} else {
-			fmt.Println(string(summaryJSON))
+			_, err := output.WriteTo(os.Stdout)
+			if err != nil {
+				klog.Fatalf("Failed to write summary: %v", err)
+			}
 		}
 	},
 }
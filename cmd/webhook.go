@@ -0,0 +1,66 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/webhook"
+)
+
+var webhookPort int
+var tlsCertFile string
+var tlsKeyFile string
+var setLimits bool
+var lowerTolerance float64
+var upperTolerance float64
+var webhookExcludeContainers string
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.PersistentFlags().IntVarP(&webhookPort, "port", "p", 8443, "The port to serve the webhook on.")
+	webhookCmd.PersistentFlags().StringVar(&tlsCertFile, "tls-cert-file", "/etc/webhook/certs/tls.crt", "File containing the x509 certificate for serving HTTPS.")
+	webhookCmd.PersistentFlags().StringVar(&tlsKeyFile, "tls-private-key-file", "/etc/webhook/certs/tls.key", "File containing the x509 private key matching --tls-cert-file.")
+	webhookCmd.PersistentFlags().StringVarP(&webhookExcludeContainers, "exclude-containers", "e", "", "Comma delimited list of containers to never apply recommendations to.")
+	webhookCmd.PersistentFlags().BoolVar(&setLimits, "set-limits", false, "Also set container limits to the recommended upper bound.")
+	webhookCmd.PersistentFlags().Float64Var(&lowerTolerance, "lower-tolerance", 0.5, "Fraction of the recommended lower bound that requests may fall below before the validating webhook reports them.")
+	webhookCmd.PersistentFlags().Float64Var(&upperTolerance, "upper-tolerance", 1, "Fraction of the recommended upper bound that requests may exceed it by before the validating webhook reports them.")
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Run the goldilocks admission webhooks that apply and check recommendations.",
+	Long: `Run the goldilocks admission webhook server.
+Pods created in namespaces labeled with goldilocks.fairwinds.com/apply-recommendations=true
+have their container requests set from the goldilocks VPA recommendation for their Deployment.
+Deployments with requests far outside the recommended bounds are warned about, or rejected in
+namespaces labeled with goldilocks.fairwinds.com/enforce-recommendations=true.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		router := webhook.GetRouter(
+			webhook.ExcludeContainers(sets.NewString(strings.Split(webhookExcludeContainers, ",")...)),
+			webhook.SetLimits(setLimits),
+			webhook.WithTolerances(lowerTolerance, upperTolerance),
+		)
+		http.Handle("/", router)
+		klog.Infof("Starting goldilocks webhook server on port %d", webhookPort)
+		klog.Fatalf("%v", http.ListenAndServeTLS(fmt.Sprintf(":%d", webhookPort), tlsCertFile, tlsKeyFile, nil))
+	},
+}
@@ -17,17 +17,21 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"k8s.io/klog"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	rt "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -35,6 +39,7 @@ import (
 	"github.com/fairwindsops/goldilocks/pkg/handler"
 	"github.com/fairwindsops/goldilocks/pkg/kube"
 	"github.com/fairwindsops/goldilocks/pkg/utils"
+	"github.com/fairwindsops/goldilocks/pkg/vpa"
 )
 
 // KubeResourceWatcher contains the informer that watches Kubernetes objects and the queue that processes updates.
@@ -134,7 +139,7 @@ func NewController(stop <-chan bool) {
 		cache.Indexers{},
 	)
 
-	DeployWatcher := createController(kubeClient.Client, DeploymentInformer, "deployment")
+	DeployWatcher := createController(kubeClient.Client, DeploymentInformer, "deployment", 0)
 	dTerm := make(chan struct{})
 	defer close(dTerm)
 	go DeployWatcher.Watch(dTerm)
@@ -154,11 +159,39 @@ func NewController(stop <-chan bool) {
 		cache.Indexers{},
 	)
 
-	NSWatcher := createController(kubeClient.Client, NSInformer, "namespace")
+	NSWatcher := createController(kubeClient.Client, NSInformer, "namespace", 0)
 	nsTerm := make(chan struct{})
 	defer close(nsTerm)
 	go NSWatcher.Watch(nsTerm)
 
+	if reconciler := vpa.GetInstance(); reconciler.AnnotateWorkloads {
+		klog.Infof("Creating watcher for VPAs.")
+		vpaClient := kube.GetVPAInstance()
+		vpaListOptions := func(options metav1.ListOptions) metav1.ListOptions {
+			options.LabelSelector = labels.Set(utils.VPALabels).String()
+			return options
+		}
+		VPAInformer := cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					return vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers("").List(context.TODO(), vpaListOptions(options))
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers("").Watch(context.TODO(), vpaListOptions(options))
+				},
+			},
+			&vpav1.VerticalPodAutoscaler{},
+			0,
+			cache.Indexers{},
+		)
+
+		// VPA status changes are debounced, so that a burst of them only annotates the workload once it has settled
+		VPAWatcher := createController(kubeClient.Client, VPAInformer, "vpa", reconciler.AnnotationDebounce)
+		vpaTerm := make(chan struct{})
+		defer close(vpaTerm)
+		go VPAWatcher.Watch(vpaTerm)
+	}
+
 	if <-stop {
 		klog.Info("Shutting down controller.")
 		return
@@ -166,9 +199,12 @@ func NewController(stop <-chan bool) {
 
 }
 
-func createController(kubeClient kubernetes.Interface, informer cache.SharedIndexInformer, resource string) *KubeResourceWatcher {
+// createController creates a watcher queueing the events of the informer.
+// Update events are only queued once an object has not been updated again for the debounce duration.
+func createController(kubeClient kubernetes.Interface, informer cache.SharedIndexInformer, resource string, debounce time.Duration) *KubeResourceWatcher {
 	klog.Infof("Creating controller for resource type %s", resource)
 	wq := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	updates := newDebouncer(wq, debounce)
 
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -207,11 +243,15 @@ func createController(kubeClient kubernetes.Interface, informer cache.SharedInde
 				klog.Errorf("Error handling update event")
 				return
 			}
+			if onlyRecommendationsChanged(old, new) {
+				klog.V(8).Infof("%s/%s only had its recommendations annotated, skipping.", resource, evt.Key)
+				return
+			}
 			evt.EventType = "update"
 			evt.ResourceType = resource
 			evt.Namespace = objectMeta(new).Namespace
 			klog.V(8).Infof("%s/%s has been updated.", resource, evt.Key)
-			wq.Add(evt)
+			updates.add(evt)
 		},
 	})
 
@@ -222,6 +262,77 @@ func createController(kubeClient kubernetes.Interface, informer cache.SharedInde
 	}
 }
 
+// debouncer queues an event once no other event with the same key has been added for its delay,
+// so that a burst of updates of an object is only handled once, after the last of them
+type debouncer struct {
+	queue workqueue.Interface
+	delay time.Duration
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// newDebouncer returns a debouncer for the queue, which queues events right away when the delay is not positive
+func newDebouncer(queue workqueue.Interface, delay time.Duration) *debouncer {
+	return &debouncer{
+		queue:  queue,
+		delay:  delay,
+		timers: map[string]*time.Timer{},
+	}
+}
+
+// add queues the event after the delay, replacing a pending event with the same key and restarting its delay
+func (d *debouncer) add(evt utils.Event) {
+	if d.delay <= 0 {
+		d.queue.Add(evt)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if pending, ok := d.timers[evt.Key]; ok {
+		pending.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		if d.timers[evt.Key] == timer {
+			delete(d.timers, evt.Key)
+		}
+		d.mu.Unlock()
+		d.queue.Add(evt)
+	})
+	d.timers[evt.Key] = timer
+}
+
+// onlyRecommendationsChanged returns whether the update of a Deployment only changed its recommendations annotation,
+// which the controller writes itself, so that annotating a Deployment does not reconcile its namespace again
+func onlyRecommendationsChanged(old, new interface{}) bool {
+	oldDeployment, ok := old.(*v1.Deployment)
+	if !ok {
+		return false
+	}
+	newDeployment, ok := new.(*v1.Deployment)
+	if !ok {
+		return false
+	}
+	if oldDeployment.Annotations[utils.RecommendationsAnnotation] == newDeployment.Annotations[utils.RecommendationsAnnotation] {
+		return false
+	}
+
+	withoutRecommendations := func(deployment *v1.Deployment) *v1.Deployment {
+		deployment = deployment.DeepCopy()
+		delete(deployment.Annotations, utils.RecommendationsAnnotation)
+		if len(deployment.Annotations) == 0 {
+			deployment.Annotations = nil
+		}
+		deployment.ResourceVersion = ""
+		deployment.ManagedFields = nil
+		return deployment
+	}
+	return equality.Semantic.DeepEqual(withoutRecommendations(oldDeployment), withoutRecommendations(newDeployment))
+}
+
 func objectMeta(obj interface{}) metav1.ObjectMeta {
 	var meta metav1.ObjectMeta
 
@@ -230,6 +341,8 @@ func objectMeta(obj interface{}) metav1.ObjectMeta {
 		meta = object.ObjectMeta
 	case *v1.Deployment:
 		meta = object.ObjectMeta
+	case *vpav1.VerticalPodAutoscaler:
+		meta = object.ObjectMeta
 	}
 	return meta
 }
@@ -16,11 +16,16 @@ package controller
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
 )
 
 func Test_objectMeta(t *testing.T) {
@@ -61,6 +66,19 @@ func Test_objectMeta(t *testing.T) {
 				Name:      "deployment",
 			},
 		},
+		{
+			name: "VPA",
+			obj: &vpav1.VerticalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vpa",
+					Namespace: "test",
+				},
+			},
+			want: metav1.ObjectMeta{
+				Namespace: "test",
+				Name:      "vpa",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -68,3 +86,56 @@ func Test_objectMeta(t *testing.T) {
 		})
 	}
 }
+
+func Test_onlyRecommendationsChanged(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test", ResourceVersion: "1"},
+	}
+
+	annotated := deployment.DeepCopy()
+	annotated.ResourceVersion = "2"
+	annotated.Annotations = map[string]string{utils.RecommendationsAnnotation: `{"app":{}}`}
+	assert.True(t, onlyRecommendationsChanged(deployment, annotated))
+
+	reannotated := annotated.DeepCopy()
+	reannotated.ResourceVersion = "3"
+	reannotated.Annotations[utils.RecommendationsAnnotation] = `{"app":{"cpu":"10m"}}`
+	assert.True(t, onlyRecommendationsChanged(annotated, reannotated))
+
+	// any other change is reconciled, even along with the annotation
+	scaled := reannotated.DeepCopy()
+	scaled.ResourceVersion = "4"
+	scaled.Annotations[utils.RecommendationsAnnotation] = `{}`
+	replicas := int32(3)
+	scaled.Spec.Replicas = &replicas
+	assert.False(t, onlyRecommendationsChanged(reannotated, scaled))
+
+	labeled := deployment.DeepCopy()
+	labeled.Labels = map[string]string{"app": "app"}
+	assert.False(t, onlyRecommendationsChanged(deployment, labeled))
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	assert.False(t, onlyRecommendationsChanged(namespace, namespace))
+}
+
+func Test_debouncer(t *testing.T) {
+	wq := workqueue.New()
+	defer wq.ShutDown()
+	d := newDebouncer(wq, 200*time.Millisecond)
+
+	// each update of the same object restarts the delay, and only the last one is queued
+	d.add(utils.Event{Key: "demo/app", EventType: "update", ResourceType: "vpa"})
+	time.Sleep(100 * time.Millisecond)
+	d.add(utils.Event{Key: "demo/app", EventType: "update", ResourceType: "vpa", Namespace: "demo"})
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, 0, wq.Len())
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, wq.Len())
+	item, _ := wq.Get()
+	assert.Equal(t, utils.Event{Key: "demo/app", EventType: "update", ResourceType: "vpa", Namespace: "demo"}, item)
+	wq.Done(item)
+
+	// without a delay, events are queued right away
+	newDebouncer(wq, 0).add(utils.Event{Key: "demo/other"})
+	assert.Equal(t, 1, wq.Len())
+}
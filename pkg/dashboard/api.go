@@ -0,0 +1,261 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+)
+
+// APIPathPrefix is the path of the versioned JSON API, below the base path of the dashboard
+const APIPathPrefix = "/api/v1"
+
+// apiNamespaceList is the response of the namespace list endpoint
+type apiNamespaceList struct {
+	APIVersion string         `json:"apiVersion"`
+	Namespaces []apiNamespace `json:"namespaces"`
+}
+
+// apiNamespace is the totals of a namespace with goldilocks VPAs, and the URL of its summary
+type apiNamespace struct {
+	Namespace  string              `json:"namespace"`
+	Workloads  int                 `json:"workloads"`
+	Delta      corev1.ResourceList `json:"delta"`
+	Cost       *summary.Cost       `json:"cost,omitempty"`
+	Efficiency *summary.Efficiency `json:"efficiency,omitempty"`
+	SummaryURL string              `json:"summaryUrl"`
+}
+
+// apiWorkload is the response of the workload endpoint
+type apiWorkload struct {
+	APIVersion string                    `json:"apiVersion"`
+	Namespace  string                    `json:"namespace"`
+	Workload   summary.DeploymentSummary `json:"workload"`
+}
+
+// apiError is the response of the API when a request fails
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// workloadFilters are the query parameters that select the workloads of a summary, by their values,
+// and the containers left out of each workload
+type workloadFilters struct {
+	workloads    sets.String
+	statuses     sets.String
+	helmReleases sets.String
+	grades       sets.String
+
+	excludedContainers sets.String
+}
+
+// APINamespaces replies with the totals of each namespace with goldilocks VPAs.
+// The grade query parameter limits them to namespaces with one of its comma separated efficiency grades.
+func APINamespaces(opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := apiSummary(w, r, opts, "")
+		if !ok {
+			return
+		}
+		grades := queryValues(r.URL.Query(), "grade")
+
+		list := apiNamespaceList{
+			APIVersion: summary.APIVersion,
+			Namespaces: []apiNamespace{},
+		}
+		for _, nsSummary := range data.Namespaces {
+			if grades.Len() > 0 && (nsSummary.Efficiency == nil || !grades.Has(nsSummary.Efficiency.Grade)) {
+				continue
+			}
+			list.Namespaces = append(list.Namespaces, apiNamespace{
+				Namespace:  nsSummary.Namespace,
+				Workloads:  len(nsSummary.Deployments),
+				Delta:      nsSummary.Delta,
+				Cost:       nsSummary.Cost,
+				Efficiency: nsSummary.Efficiency,
+				SummaryURL: path.Join(opts.basePath, APIPathPrefix, "namespaces", nsSummary.Namespace, "summary"),
+			})
+		}
+		sort.Slice(list.Namespaces, func(i, j int) bool {
+			return list.Namespaces[i].Namespace < list.Namespaces[j].Namespace
+		})
+		writeJSON(w, http.StatusOK, list)
+	})
+}
+
+// APISummary replies with the summary of a namespace, or of all namespaces without one.
+// A POST refreshes the cached summary first.
+// The workload, status, helmRelease and grade query parameters limit the workloads to those with one of their comma
+// separated values, and the excludeContainers query parameter leaves its comma separated containers out of each workload.
+// The totals of each workload, namespace and the cluster remain those of the cached summary.
+func APISummary(opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := apiSummary(w, r, opts, mux.Vars(r)["namespace"])
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, newWorkloadFilters(r.URL.Query()).filter(data))
+	})
+}
+
+// APIWorkload replies with the summary of a single workload, or not found if it does not have a goldilocks VPA.
+// The excludeContainers query parameter leaves its comma separated containers out of it.
+func APIWorkload(opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		data, ok := apiSummary(w, r, opts, vars["namespace"])
+		if !ok {
+			return
+		}
+		dSummary, ok := data.Namespaces[vars["namespace"]].Deployments[vars["name"]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, apiError{Error: fmt.Sprintf("workload %s/%s not found", vars["namespace"], vars["name"])})
+			return
+		}
+		writeJSON(w, http.StatusOK, apiWorkload{
+			APIVersion: summary.APIVersion,
+			Namespace:  vars["namespace"],
+			Workload:   newWorkloadFilters(r.URL.Query()).withoutExcludedContainers(dSummary),
+		})
+	})
+}
+
+// apiSummary returns the cached summary of the namespace, or all namespaces when it is empty, which is refreshed first
+// for a POST. It replies with an error when the summary fails, and returns whether it succeeded.
+func apiSummary(w http.ResponseWriter, r *http.Request, opts Options, namespace string) (summary.Summary, bool) {
+	data, _, err := opts.summaries.summary(namespace, r.Method == http.MethodPost)
+	if err != nil {
+		klog.Errorf("Error getting vpaData: %v", err)
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: "error running summary"})
+		return data, false
+	}
+	return data, true
+}
+
+// newWorkloadFilters returns the workload filters of the query parameters
+func newWorkloadFilters(query url.Values) workloadFilters {
+	return workloadFilters{
+		workloads:    queryValues(query, "workload"),
+		statuses:     queryValues(query, "status"),
+		helmReleases: queryValues(query, "helmRelease"),
+		grades:       queryValues(query, "grade"),
+
+		excludedContainers: queryValues(query, "excludeContainers"),
+	}
+}
+
+// filter returns a copy of the summary with only the workloads that match the filters, leaving the cached summary as it is
+func (f workloadFilters) filter(data summary.Summary) summary.Summary {
+	filtered := data
+	filtered.Namespaces = map[string]summary.NamespaceSummary{}
+	for name, nsSummary := range data.Namespaces {
+		deployments := map[string]summary.DeploymentSummary{}
+		for dName, dSummary := range nsSummary.Deployments {
+			if f.matches(dSummary) {
+				deployments[dName] = f.withoutExcludedContainers(dSummary)
+			}
+		}
+		nsSummary.Deployments = deployments
+		filtered.Namespaces[name] = nsSummary
+	}
+	return filtered
+}
+
+// withoutExcludedContainers returns a copy of the workload without the excluded containers, leaving the cached summary
+// as it is. Its totals are still those of all of its containers.
+func (f workloadFilters) withoutExcludedContainers(dSummary summary.DeploymentSummary) summary.DeploymentSummary {
+	if f.excludedContainers.Len() <= 0 {
+		return dSummary
+	}
+	containers := map[string]summary.ContainerSummary{}
+	for name, cSummary := range dSummary.Containers {
+		if !f.excludedContainers.Has(name) {
+			containers[name] = cSummary
+		}
+	}
+	dSummary.Containers = containers
+	if dSummary.Uncovered != nil {
+		uncovered := map[string]summary.Recommendation{}
+		for name, rec := range dSummary.Uncovered {
+			if !f.excludedContainers.Has(name) {
+				uncovered[name] = rec
+			}
+		}
+		dSummary.Uncovered = uncovered
+	}
+	return dSummary
+}
+
+// matches returns whether the workload has one of the values of each filter that is set
+func (f workloadFilters) matches(dSummary summary.DeploymentSummary) bool {
+	grade := ""
+	if dSummary.Efficiency != nil {
+		grade = dSummary.Efficiency.Grade
+	}
+	for _, filter := range []struct {
+		values sets.String
+		value  string
+	}{
+		{f.workloads, dSummary.DeploymentName},
+		{f.statuses, dSummary.Status},
+		{f.helmReleases, dSummary.HelmRelease},
+		{f.grades, grade},
+	} {
+		if filter.values.Len() > 0 && !filter.values.Has(filter.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryValues returns the comma separated values of the query parameter, which may be repeated
+func queryValues(query url.Values, name string) sets.String {
+	values := sets.NewString()
+	for _, value := range query[name] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values.Insert(v)
+			}
+		}
+	}
+	return values
+}
+
+// writeJSON replies with the status and the data as JSON
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		klog.Errorf("Error serializing API response: %v", err)
+		http.Error(w, "Error serializing API response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		klog.Errorf("Error writing API response: %v", err)
+	}
+}
@@ -12,6 +12,22 @@ $(function () {
     $(this).parents('.resource-info').toggleClass('expanded');
   });
 
+  // sorts the deployments of each namespace by name, or by their total impact with the largest first
+  $('#sort-deployments').on('change', function () {
+    var key = $(this).val();
+    $('.card.namespace .expandable-table').each(function () {
+      var $table = $(this);
+      var deployments = $table.children('.resource-info').get();
+      deployments.sort(function (a, b) {
+        if (key === 'name') {
+          return $(a).attr('data-name').localeCompare($(b).attr('data-name'));
+        }
+        return parseFloat($(b).attr('data-' + key)) - parseFloat($(a).attr('data-' + key));
+      });
+      $table.append($(deployments));
+    });
+  });
+
   var expandMatch = window.location.search.match(/expand=(\w+)(\W|$)/);
   if (expandMatch && expandMatch[1] !== 'false' && expandMatch[1] !== '0') {
     $('.resource-info').addClass('expanded');
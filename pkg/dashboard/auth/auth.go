@@ -0,0 +1,120 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"k8s.io/klog"
+)
+
+// User is an authenticated user of the dashboard
+type User struct {
+	Name   string
+	Groups []string
+}
+
+// Authenticator authenticates requests to the dashboard
+type Authenticator interface {
+	// Authenticate returns the user of the request, or nil when the request does not have credentials for the
+	// Authenticator. It returns an error when the request has credentials for it that are not valid.
+	Authenticate(r *http.Request) (*User, error)
+}
+
+// Login is an Authenticator that can send users of a browser to log in
+type Login interface {
+	Authenticator
+
+	// Login redirects the request to log in, and to returnTo once the user has logged in
+	Login(w http.ResponseWriter, r *http.Request, returnTo string)
+
+	// Callback is the handler of CallbackPath, which users are redirected to after they log in.
+	// It must be served without authentication.
+	Callback() http.Handler
+}
+
+// CallbackPath is the path of the Login callback, below the base path of the dashboard
+const CallbackPath = "/oauth2/callback"
+
+// Middleware returns a middleware that only serves requests that one of the authenticators authenticates.
+// Other requests from a browser are redirected to log in by the first Login of the authenticators, and the
+// rest are unauthorized. The base path of the dashboard is used to return to the requested page after logging in.
+func Middleware(basePath string, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	var login Login
+	for _, authenticator := range authenticators {
+		if l, ok := authenticator.(Login); ok {
+			login = l
+			break
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := authenticate(r, authenticators)
+			if user != nil {
+				klog.V(4).Infof("Authenticated %s for %s %s", user.Name, r.Method, r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				klog.V(2).Infof("Failed to authenticate %s %s: %v", r.Method, r.URL.Path, err)
+			} else if login != nil && acceptsHTML(r) {
+				returnTo := path.Join(basePath, r.URL.Path)
+				if r.URL.RawQuery != "" {
+					returnTo += "?" + r.URL.RawQuery
+				}
+				login.Login(w, r, returnTo)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goldilocks"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+// authenticate returns the user of the first authenticator that authenticates the request,
+// or the first error of the authenticators when none of them does
+func authenticate(r *http.Request, authenticators []Authenticator) (*User, error) {
+	var firstErr error
+	for _, authenticator := range authenticators {
+		user, err := authenticator.Authenticate(r)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if user != nil {
+			return user, nil
+		}
+	}
+	return nil, firstErr
+}
+
+// acceptsHTML returns whether the request is for a page of a browser, rather than the API or a script
+func acceptsHTML(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// bearerToken returns the bearer token of the Authorization header of the request, or an empty string
+func bearerToken(r *http.Request) string {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidc := newTestOIDC(t, issuer)
+	tokens := &StaticTokens{Tokens: []StaticToken{{Token: "script-token", User: "script"}}}
+	handler := Middleware("/goldilocks", tokens, oidc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+
+	serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// static tokens and ID tokens are both accepted as bearer tokens
+	w := serve("/api/v1/summary", map[string]string{"Authorization": "Bearer script-token"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "OK", w.Body.String())
+	w = serve("/api/v1/summary", map[string]string{"Authorization": "Bearer " + issuer.sign(t, issuer.validClaims())})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// requests without valid credentials are unauthorized, even from a browser when a bearer token is not valid
+	w = serve("/api/v1/summary", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="goldilocks"`, w.Header().Get("WWW-Authenticate"))
+	w = serve("/namespaces", map[string]string{"Accept": "text/html", "Authorization": "Bearer wrong-token"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// browsers are redirected to log in, and back to the page below the base path afterwards
+	w = serve("/dashboard/demo?refresh=true", map[string]string{"Accept": "text/html,application/xhtml+xml"})
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/authorize", location.Path)
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	r := httptest.NewRequest(http.MethodGet, CallbackPath, nil)
+	r.AddCookie(cookies[0])
+	state, err := readLoginState(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "/goldilocks/dashboard/demo?refresh=true", state.ReturnTo)
+
+	// without a login, browsers are unauthorized too
+	handler = Middleware("/", tokens)(http.NotFoundHandler())
+	w = serve("/namespaces", map[string]string{"Accept": "text/html"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"Bearer abc", "abc"},
+		{"bearer  abc ", "abc"},
+		{"Basic abc", ""},
+		{"Bearer", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.header)
+		assert.Equal(t, tt.want, bearerToken(r), tt.header)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+	"k8s.io/klog"
+)
+
+const (
+	// sessionCookie holds the signed session of a user who logged in
+	sessionCookie = "goldilocks_session"
+	// loginCookie holds the state of a login until its callback
+	loginCookie = "goldilocks_login"
+	// loginTimeout is how long a user has to log in with the issuer
+	loginTimeout = 10 * time.Minute
+)
+
+// OIDCConfig is the configuration of an OpenID Connect issuer and the dashboard's client of it
+type OIDCConfig struct {
+	// IssuerURL is the URL of the issuer, which serves its discovery document below /.well-known/openid-configuration
+	IssuerURL string
+
+	// ClientID and ClientSecret are the credentials of the dashboard's client. ID tokens must have the client ID as an audience.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the URL of the CallbackPath of the dashboard, as users reach it
+	RedirectURL string
+
+	// Scopes are requested when logging in, in addition to openid
+	Scopes []string
+
+	// UsernameClaim is the claim of ID tokens with the name of the user, sub by default
+	UsernameClaim string
+
+	// GroupsClaim is the claim of ID tokens with the groups of the user, which is optional
+	GroupsClaim string
+
+	// HTTPClient is used to reach the issuer, or a client with a timeout when it is nil
+	HTTPClient *http.Client
+}
+
+// OIDC authenticates the ID tokens of an OpenID Connect issuer from bearer tokens, and the sessions of users who logged in
+type OIDC struct {
+	config     OIDCConfig
+	oauth2     oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	sessionKey []byte
+}
+
+// loginState is the state of a login, which is kept in a cookie until its callback
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	ReturnTo string `json:"returnTo"`
+}
+
+// NewOIDC returns an OIDC authenticator for the issuer, whose endpoints are read from its discovery document
+func NewOIDC(ctx context.Context, config OIDCConfig) (*OIDC, error) {
+	if config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("an OIDC issuer URL, client ID and redirect URL are required")
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	// the signing keys of the issuer are fetched by the verifier with the same client, when it sees a new key ID
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, config.HTTPClient), config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching OIDC discovery document: %v", err)
+	}
+
+	return &OIDC{
+		config: config,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       append([]string{oidc.ScopeOpenID}, config.Scopes...),
+			Endpoint:     provider.Endpoint(),
+		},
+		verifier: provider.Verifier(&oidc.Config{
+			ClientID:             config.ClientID,
+			SupportedSigningAlgs: []string{oidc.RS256, oidc.ES256},
+		}),
+		sessionKey: newSessionKey(config.ClientSecret),
+	}, nil
+}
+
+// Authenticate returns the user of the ID token in the bearer token, or of the session cookie of the request.
+// A bearer token that is not a valid ID token is an error, but a session that is not valid, such as one that
+// has expired, is ignored so that the user logs in again.
+func (o *OIDC) Authenticate(r *http.Request) (*User, error) {
+	if token := bearerToken(r); token != "" {
+		user, _, err := o.verify(r.Context(), token, "")
+		return user, err
+	}
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+	s, err := decodeSession(cookie.Value, o.sessionKey)
+	if err != nil {
+		klog.V(2).Infof("Ignoring session that is not valid: %v", err)
+		return nil, nil
+	}
+	return &User{Name: s.User, Groups: s.Groups}, nil
+}
+
+// Login redirects the request to the authorization endpoint of the issuer, keeping the state of the login in a cookie
+func (o *OIDC) Login(w http.ResponseWriter, r *http.Request, returnTo string) {
+	state := loginState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		ReturnTo: returnTo,
+	}
+	value, err := json.Marshal(state)
+	if err != nil {
+		klog.Errorf("Error serializing login state: %v", err)
+		http.Error(w, "Error logging in", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, o.cookie(loginCookie, base64.RawURLEncoding.EncodeToString(value), time.Now().Add(loginTimeout)))
+	http.Redirect(w, r, o.oauth2.AuthCodeURL(state.State, oauth2.SetAuthURLParam("nonce", state.Nonce)), http.StatusFound)
+}
+
+// Callback returns the handler that exchanges the authorization code for an ID token, keeps its user in a signed
+// session cookie until the ID token expires, and redirects the user to the page they requested before logging in
+func (o *OIDC) Callback() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if errCode := query.Get("error"); errCode != "" {
+			klog.Errorf("Error logging in: %s %s", errCode, query.Get("error_description"))
+			http.Error(w, "Error logging in", http.StatusUnauthorized)
+			return
+		}
+
+		state, err := readLoginState(r)
+		if err != nil || subtle.ConstantTimeCompare([]byte(state.State), []byte(query.Get("state"))) != 1 {
+			klog.V(2).Infof("Login callback without a matching state: %v", err)
+			http.Error(w, "Login expired, please try again", http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, o.cookie(loginCookie, "", time.Unix(0, 0)))
+
+		ctx := context.WithValue(r.Context(), oauth2.HTTPClient, o.config.HTTPClient)
+		token, err := o.oauth2.Exchange(ctx, query.Get("code"))
+		if err != nil {
+			klog.Errorf("Error exchanging the authorization code: %v", err)
+			http.Error(w, "Error logging in", http.StatusUnauthorized)
+			return
+		}
+		idToken, ok := token.Extra("id_token").(string)
+		if !ok || idToken == "" {
+			klog.Errorf("Error logging in, the token response does not have an ID token")
+			http.Error(w, "Error logging in", http.StatusUnauthorized)
+			return
+		}
+		user, expiry, err := o.verify(ctx, idToken, state.Nonce)
+		if err != nil {
+			klog.Errorf("Error verifying the ID token: %v", err)
+			http.Error(w, "Error logging in", http.StatusUnauthorized)
+			return
+		}
+		value, err := encodeSession(session{User: user.Name, Groups: user.Groups, Expires: expiry.Unix()}, o.sessionKey)
+		if err != nil {
+			klog.Errorf("Error creating the session: %v", err)
+			http.Error(w, "Error logging in", http.StatusInternalServerError)
+			return
+		}
+		klog.V(2).Infof("User %s logged in", user.Name)
+
+		http.SetCookie(w, o.cookie(sessionCookie, value, expiry))
+		http.Redirect(w, r, localPath(state.ReturnTo), http.StatusFound)
+	})
+}
+
+// verify returns the user of a valid ID token of the issuer for the client, which must have the nonce when it is set,
+// and when the ID token expires
+func (o *OIDC) verify(ctx context.Context, raw, nonce string) (*User, time.Time, error) {
+	idToken, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, time.Time{}, fmt.Errorf("ID token nonce does not match the login")
+	}
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	name, _ := claims[o.config.UsernameClaim].(string)
+	if name == "" {
+		return nil, time.Time{}, fmt.Errorf("ID token does not have a %s claim", o.config.UsernameClaim)
+	}
+	if o.config.UsernameClaim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return nil, time.Time{}, fmt.Errorf("ID token email %q is not verified", name)
+		}
+	}
+	user := &User{Name: name}
+	if o.config.GroupsClaim != "" {
+		user.Groups = stringsClaim(claims[o.config.GroupsClaim])
+	}
+	return user, idToken.Expiry, nil
+}
+
+// cookie returns a cookie for the dashboard, which is secure when the dashboard is reached over https
+func (o *OIDC) cookie(name, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   strings.HasPrefix(o.config.RedirectURL, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// readLoginState returns the login state of the login cookie of the request
+func readLoginState(r *http.Request) (loginState, error) {
+	state := loginState{}
+	cookie, err := r.Cookie(loginCookie)
+	if err != nil {
+		return state, err
+	}
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(value, &state); err != nil {
+		return state, err
+	}
+	if state.State == "" {
+		return state, fmt.Errorf("login state is empty")
+	}
+	return state, nil
+}
+
+// stringsClaim returns the values of a claim that is a string or a list of strings
+func stringsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// localPath returns the path if it is on the dashboard's host, or the root so that a login cannot redirect elsewhere
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// randomString returns a random string for the state and nonce of a login
+func randomString() string {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		klog.Fatalf("Error reading random data: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
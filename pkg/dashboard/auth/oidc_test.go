@@ -0,0 +1,326 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientID = "goldilocks"
+
+// mockIssuer is a local OpenID Connect issuer, which signs ID tokens with its current key and issues a token with
+// the claims and nonce for the valid authorization code
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	// claims are the claims of the ID token of the token endpoint, which gets the nonce of the last login
+	claims map[string]interface{}
+	nonce  string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	issuer := &mockIssuer{}
+	issuer.rotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]map[string]string{
+			"keys": {{
+				"kty": "RSA",
+				"kid": issuer.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "valid-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := map[string]interface{}{}
+		for k, v := range issuer.claims {
+			claims[k] = v
+		}
+		claims["nonce"] = issuer.nonce
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     issuer.sign(t, claims),
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	issuer.claims = issuer.validClaims()
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// rotateKey replaces the signing key of the issuer with a new key
+func (i *mockIssuer) rotateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	i.key = key
+	i.kid = randomString()
+}
+
+// validClaims returns the claims of a valid ID token for the test client
+func (i *mockIssuer) validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    i.server.URL,
+		"aud":    testClientID,
+		"sub":    "1234",
+		"email":  "jane@example.com",
+		"groups": []interface{}{"admins", "viewers"},
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// signTestJWT returns a JSON Web Token of the header and claims, signed with the RSA key, or without a signature when
+// the key is nil
+func signTestJWT(t *testing.T, header map[string]interface{}, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	segments := []string{}
+	for _, part := range []interface{}{header, claims} {
+		data, err := json.Marshal(part)
+		assert.NoError(t, err)
+		segments = append(segments, base64.RawURLEncoding.EncodeToString(data))
+	}
+	signingInput := segments[0] + "." + segments[1]
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	if key != nil {
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// sign returns the ID token of the claims, signed with the current key of the issuer
+func (i *mockIssuer) sign(t *testing.T, claims map[string]interface{}) string {
+	return signTestJWT(t, map[string]interface{}{"alg": "RS256", "kid": i.kid}, claims, i.key)
+}
+
+func newTestOIDC(t *testing.T, issuer *mockIssuer) *OIDC {
+	oidc, err := NewOIDC(context.TODO(), OIDCConfig{
+		IssuerURL:     issuer.server.URL,
+		ClientID:      testClientID,
+		ClientSecret:  "secret",
+		RedirectURL:   "https://goldilocks.example.com" + CallbackPath,
+		UsernameClaim: "email",
+		GroupsClaim:   "groups",
+	})
+	assert.NoError(t, err)
+	return oidc
+}
+
+func TestNewOIDC(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidc := newTestOIDC(t, issuer)
+	assert.Equal(t, issuer.server.URL+"/authorize", oidc.oauth2.Endpoint.AuthURL)
+	assert.Equal(t, issuer.server.URL+"/token", oidc.oauth2.Endpoint.TokenURL)
+	assert.Equal(t, []string{"openid"}, oidc.oauth2.Scopes)
+
+	// the discovery document must be for the configured issuer
+	_, err := NewOIDC(context.TODO(), OIDCConfig{IssuerURL: issuer.server.URL + "/other", ClientID: testClientID, RedirectURL: "https://goldilocks.example.com" + CallbackPath})
+	assert.Error(t, err)
+	_, err = NewOIDC(context.TODO(), OIDCConfig{IssuerURL: issuer.server.URL, ClientID: testClientID})
+	assert.EqualError(t, err, "an OIDC issuer URL, client ID and redirect URL are required")
+}
+
+func TestOIDCBearerToken(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidc := newTestOIDC(t, issuer)
+
+	tests := []struct {
+		name   string
+		modify func(claims map[string]interface{})
+		want   *User
+	}{
+		{"valid", func(claims map[string]interface{}) {}, &User{Name: "jane@example.com", Groups: []string{"admins", "viewers"}}},
+		{"audience list", func(claims map[string]interface{}) { claims["aud"] = []interface{}{"other", testClientID} }, &User{Name: "jane@example.com", Groups: []string{"admins", "viewers"}}},
+		{"single group", func(claims map[string]interface{}) { claims["groups"] = "admins" }, &User{Name: "jane@example.com", Groups: []string{"admins"}}},
+		{"expired", func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, nil},
+		{"no expiry", func(claims map[string]interface{}) { delete(claims, "exp") }, nil},
+		{"other issuer", func(claims map[string]interface{}) { claims["iss"] = "https://other.example.com" }, nil},
+		{"other audience", func(claims map[string]interface{}) { claims["aud"] = "other" }, nil},
+		{"no username", func(claims map[string]interface{}) { delete(claims, "email") }, nil},
+		{"unverified email", func(claims map[string]interface{}) { claims["email_verified"] = false }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issuer.validClaims()
+			tt.modify(claims)
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/summary", nil)
+			r.Header.Set("Authorization", "Bearer "+issuer.sign(t, claims))
+
+			got, err := oidc.Authenticate(r)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want == nil, err != nil)
+		})
+	}
+
+	// tokens signed with another key or without a signature are not valid
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	forged := signTestJWT(t, map[string]interface{}{"alg": "RS256", "kid": issuer.kid}, issuer.validClaims(), other)
+	unsigned := signTestJWT(t, map[string]interface{}{"alg": "none"}, issuer.validClaims(), nil)
+	for _, token := range []string{forged, unsigned} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		got, err := oidc.Authenticate(r)
+		assert.Error(t, err)
+		assert.Nil(t, got)
+	}
+
+	// without a bearer token or session there is nothing to authenticate
+	got, err := oidc.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidc := newTestOIDC(t, issuer)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+issuer.sign(t, issuer.validClaims()))
+	_, err := oidc.Authenticate(r)
+	assert.NoError(t, err)
+
+	// a token signed with a new key fetches the keys again
+	issuer.rotateKey(t)
+	r.Header.Set("Authorization", "Bearer "+issuer.sign(t, issuer.validClaims()))
+	got, err := oidc.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", got.Name)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	oidc := newTestOIDC(t, issuer)
+
+	// logging in redirects to the issuer with the state and nonce kept in a cookie
+	w := httptest.NewRecorder()
+	oidc.Login(w, httptest.NewRequest(http.MethodGet, "/dashboard/demo", nil), "/dashboard/demo?refresh=true")
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, issuer.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, testClientID, location.Query().Get("client_id"))
+	assert.Equal(t, "openid", location.Query().Get("scope"))
+	assert.Equal(t, "https://goldilocks.example.com/oauth2/callback", location.Query().Get("redirect_uri"))
+
+	loginCookies := w.Result().Cookies()
+	assert.Len(t, loginCookies, 1)
+	assert.Equal(t, loginCookie, loginCookies[0].Name)
+	assert.True(t, loginCookies[0].HttpOnly)
+	assert.True(t, loginCookies[0].Secure)
+
+	callback := func(query string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, CallbackPath+"?"+query, nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		oidc.Callback().ServeHTTP(w, r)
+		return w
+	}
+	state := location.Query().Get("state")
+
+	// the callback must have the state of the login, and an authorization code for a token with its nonce
+	assert.Equal(t, http.StatusBadRequest, callback("code=valid-code&state="+state, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, callback("code=valid-code&state=other", loginCookies).Code)
+	assert.Equal(t, http.StatusUnauthorized, callback("error=access_denied&state="+state, loginCookies).Code)
+	assert.Equal(t, http.StatusUnauthorized, callback("code=invalid-code&state="+state, loginCookies).Code)
+	issuer.nonce = "other"
+	assert.Equal(t, http.StatusUnauthorized, callback("code=valid-code&state="+state, loginCookies).Code)
+
+	issuer.nonce = location.Query().Get("nonce")
+	w = callback("code=valid-code&state="+state, loginCookies)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/dashboard/demo?refresh=true", w.Header().Get("Location"))
+
+	// the session cookie authenticates the user, and the login cookie is cleared
+	var session *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		switch cookie.Name {
+		case sessionCookie:
+			session = cookie
+		case loginCookie:
+			assert.Empty(t, cookie.Value)
+		}
+	}
+	assert.NotNil(t, session)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.Expires, time.Minute)
+	r := httptest.NewRequest(http.MethodGet, "/namespaces", nil)
+	r.AddCookie(session)
+	got, err := oidc.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &User{Name: "jane@example.com", Groups: []string{"admins", "viewers"}}, got)
+
+	// the session is signed, and not the ID token itself
+	assert.Len(t, strings.Split(session.Value, "."), 2)
+	other := newTestOIDC(t, issuer)
+	other.sessionKey = newSessionKey("other")
+	got, err = other.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// a session that is not valid is ignored, so that the user logs in again
+	r = httptest.NewRequest(http.MethodGet, "/namespaces", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: "expired"})
+	got, err = oidc.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestLocalPath(t *testing.T) {
+	assert.Equal(t, "/dashboard?refresh=true", localPath("/dashboard?refresh=true"))
+	assert.Equal(t, "/", localPath("https://evil.example.com"))
+	assert.Equal(t, "/", localPath("//evil.example.com"))
+	assert.Equal(t, "/", localPath("/\\evil.example.com"))
+	assert.Equal(t, "/", localPath(""))
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProxyHeaders authenticates requests with the user and groups headers of an auth proxy in front of the dashboard,
+// such as oauth2-proxy. Only the headers of requests from the trusted CIDRs of the proxies are trusted.
+type ProxyHeaders struct {
+	// UserHeader is the header with the name of the user
+	UserHeader string
+
+	// GroupsHeader is the header with the comma separated groups of the user, which may be repeated
+	GroupsHeader string
+
+	// TrustedCIDRs are the addresses of the proxies whose headers are trusted, which trusts none when it is empty
+	TrustedCIDRs []*net.IPNet
+}
+
+// ParseCIDRs parses the CIDRs of trusted proxies
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// Authenticate returns the user of the user header, or nil when the request does not have one.
+// It returns an error when the request is not from one of the trusted CIDRs.
+func (p ProxyHeaders) Authenticate(r *http.Request) (*User, error) {
+	name := strings.TrimSpace(r.Header.Get(p.UserHeader))
+	if name == "" {
+		return nil, nil
+	}
+	if !p.trusted(r.RemoteAddr) {
+		return nil, fmt.Errorf("ignoring %s header from untrusted address %s", p.UserHeader, r.RemoteAddr)
+	}
+
+	user := &User{Name: name}
+	if p.GroupsHeader != "" {
+		for _, value := range r.Header.Values(p.GroupsHeader) {
+			for _, group := range strings.Split(value, ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					user.Groups = append(user.Groups, group)
+				}
+			}
+		}
+	}
+	return user, nil
+}
+
+// trusted returns whether the remote address is in one of the trusted CIDRs
+func (p ProxyHeaders) trusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range p.TrustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
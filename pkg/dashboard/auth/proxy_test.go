@@ -0,0 +1,65 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyHeadersAuthenticate(t *testing.T) {
+	cidrs, err := ParseCIDRs([]string{"10.0.0.0/8", " 192.168.1.1/32"})
+	assert.NoError(t, err)
+	proxy := ProxyHeaders{
+		UserHeader:   "X-Forwarded-User",
+		GroupsHeader: "X-Forwarded-Groups",
+		TrustedCIDRs: cidrs,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.1.2.3:52000"
+	r.Header.Set("X-Forwarded-User", "jane")
+	r.Header.Add("X-Forwarded-Groups", "admins, viewers")
+	r.Header.Add("X-Forwarded-Groups", "ops")
+	got, err := proxy.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &User{Name: "jane", Groups: []string{"admins", "viewers", "ops"}}, got)
+
+	// headers from other addresses are not trusted
+	r.RemoteAddr = "172.16.0.1:52000"
+	got, err = proxy.Authenticate(r)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+
+	// without a user header there is nothing to authenticate
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	got, err = proxy.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// without trusted CIDRs the headers of no address are trusted
+	proxy.TrustedCIDRs = nil
+	r.RemoteAddr = "10.1.2.3:52000"
+	r.Header.Set("X-Forwarded-User", "jane")
+	got, err = proxy.Authenticate(r)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+
+	_, err = ParseCIDRs([]string{"10.0.0.1"})
+	assert.Error(t, err)
+}
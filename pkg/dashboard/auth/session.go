@@ -0,0 +1,97 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSessionSize is the most bytes of the value of a session cookie, well below the 4096 bytes that browsers keep
+const maxSessionSize = 3072
+
+// session is the user who logged in, which the session cookie keeps signed with the session key until it expires
+type session struct {
+	User    string   `json:"user"`
+	Groups  []string `json:"groups,omitempty"`
+	Expires int64    `json:"exp"`
+}
+
+// newSessionKey returns the key that sessions are signed with. It is derived from the client secret, so that every
+// replica of the dashboard accepts the sessions of the others, or random for a client without a secret.
+func newSessionKey(clientSecret string) []byte {
+	if clientSecret == "" {
+		return []byte(randomString())
+	}
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	_, _ = mac.Write([]byte("goldilocks session"))
+	return mac.Sum(nil)
+}
+
+// encodeSession returns the cookie value of the session, signed with the key. The last groups are left out when the
+// session would not fit in a cookie otherwise.
+func encodeSession(s session, key []byte) (string, error) {
+	for {
+		payload, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		value := base64.RawURLEncoding.EncodeToString(payload)
+		value += "." + base64.RawURLEncoding.EncodeToString(signSession(value, key))
+		if len(value) <= maxSessionSize {
+			return value, nil
+		}
+		if len(s.Groups) <= 0 {
+			return "", fmt.Errorf("session of user %q is larger than %d bytes", s.User, maxSessionSize)
+		}
+		s.Groups = s.Groups[:len(s.Groups)-1]
+	}
+}
+
+// decodeSession returns the session of a cookie value that is signed with the key and has not expired
+func decodeSession(value string, key []byte) (session, error) {
+	s := session{}
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 {
+		return s, fmt.Errorf("session is not signed")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signSession(parts[0], key)) {
+		return s, fmt.Errorf("session signature is not valid")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return s, err
+	}
+	if !time.Now().Before(time.Unix(s.Expires, 0)) {
+		return s, fmt.Errorf("session of user %q expired", s.User)
+	}
+	return s, nil
+}
+
+// signSession returns the HMAC-SHA256 of the encoded session with the key
+func signSession(encoded string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
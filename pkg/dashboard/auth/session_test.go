@@ -0,0 +1,68 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	key := newSessionKey("secret")
+	assert.Equal(t, key, newSessionKey("secret"))
+	assert.NotEqual(t, key, newSessionKey("other"))
+
+	s := session{User: "jane@example.com", Groups: []string{"admins", "viewers"}, Expires: time.Now().Add(time.Hour).Unix()}
+	value, err := encodeSession(s, key)
+	assert.NoError(t, err)
+	got, err := decodeSession(value, key)
+	assert.NoError(t, err)
+	assert.Equal(t, s, got)
+
+	// sessions signed with another key, changed, or expired are not valid
+	_, err = decodeSession(value, newSessionKey("other"))
+	assert.EqualError(t, err, "session signature is not valid")
+	forged, err := encodeSession(session{User: "admin", Expires: s.Expires}, key)
+	assert.NoError(t, err)
+	_, err = decodeSession(strings.Split(forged, ".")[0]+"."+strings.Split(value, ".")[1], key)
+	assert.EqualError(t, err, "session signature is not valid")
+	_, err = decodeSession("jane", key)
+	assert.EqualError(t, err, "session is not signed")
+	expired, err := encodeSession(session{User: "jane@example.com", Expires: time.Now().Add(-time.Minute).Unix()}, key)
+	assert.NoError(t, err)
+	_, err = decodeSession(expired, key)
+	assert.EqualError(t, err, `session of user "jane@example.com" expired`)
+
+	// the last groups are left out of a session that would not fit in a cookie
+	s.Groups = nil
+	for i := 0; i < 200; i++ {
+		s.Groups = append(s.Groups, fmt.Sprintf("group-%03d", i))
+	}
+	value, err = encodeSession(s, key)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(value), maxSessionSize)
+	got, err = decodeSession(value, key)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, got.Groups)
+	assert.Equal(t, s.Groups[:len(got.Groups)], got.Groups)
+	assert.Less(t, len(got.Groups), len(s.Groups))
+
+	_, err = encodeSession(session{User: strings.Repeat("a", maxSessionSize)}, key)
+	assert.Error(t, err)
+}
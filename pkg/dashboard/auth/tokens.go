@@ -0,0 +1,76 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"sigs.k8s.io/yaml"
+)
+
+// StaticTokens authenticates requests with one of a fixed set of bearer tokens, such as those of scripts
+type StaticTokens struct {
+	Tokens []StaticToken `json:"tokens"`
+}
+
+// StaticToken is a bearer token and the user it authenticates
+type StaticToken struct {
+	Token  string   `json:"token"`
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// LoadStaticTokens reads StaticTokens from a YAML or JSON file
+func LoadStaticTokens(path string) (*StaticTokens, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseStaticTokens(data)
+}
+
+func parseStaticTokens(data []byte) (*StaticTokens, error) {
+	tokens := &StaticTokens{}
+	if err := yaml.UnmarshalStrict(data, tokens); err != nil {
+		return nil, err
+	}
+	for i, token := range tokens.Tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("invalid token %d, must not be empty", i)
+		}
+		if token.User == "" {
+			return nil, fmt.Errorf("invalid token %d, must have a user", i)
+		}
+	}
+	return tokens, nil
+}
+
+// Authenticate returns the user of the bearer token of the request, or nil when it does not have one of the tokens.
+// Tokens that are not one of the static tokens are left to other authenticators, such as OIDC.
+func (t StaticTokens) Authenticate(r *http.Request) (*User, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	for _, static := range t.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(static.Token)) == 1 {
+			return &User{Name: static.User, Groups: static.Groups}, nil
+		}
+	}
+	return nil, nil
+}
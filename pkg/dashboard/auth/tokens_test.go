@@ -0,0 +1,67 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStaticTokens(t *testing.T) {
+	tokens, err := parseStaticTokens([]byte(`
+tokens:
+- token: abc
+  user: ci
+  groups: [readers]
+- token: def
+  user: reports
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, &StaticTokens{Tokens: []StaticToken{
+		{Token: "abc", User: "ci", Groups: []string{"readers"}},
+		{Token: "def", User: "reports"},
+	}}, tokens)
+
+	_, err = parseStaticTokens([]byte("tokens:\n- user: ci\n"))
+	assert.EqualError(t, err, "invalid token 0, must not be empty")
+	_, err = parseStaticTokens([]byte("tokens:\n- token: abc\n"))
+	assert.EqualError(t, err, "invalid token 0, must have a user")
+	_, err = parseStaticTokens([]byte("tokens:\n- token: abc\n  name: ci\n"))
+	assert.Error(t, err)
+}
+
+func TestStaticTokensAuthenticate(t *testing.T) {
+	tokens := StaticTokens{Tokens: []StaticToken{{Token: "abc", User: "ci", Groups: []string{"readers"}}}}
+
+	tests := []struct {
+		header string
+		want   *User
+	}{
+		{"Bearer abc", &User{Name: "ci", Groups: []string{"readers"}}},
+		{"Bearer abcd", nil},
+		{"Basic abc", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.header)
+		got, err := tokens.Authenticate(r)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.header)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"net/http"
+
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+)
+
+// Coverage replies with the rendered coverage report of every workload in the cluster
+func Coverage(opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if refreshPage(w, r, opts, cacheKey{kind: coverageKind}) {
+			return
+		}
+
+		coverage, refreshed, err := opts.summaries.coverage(false)
+		if err != nil {
+			klog.Errorf("Error getting coverage: %v", err)
+			http.Error(w, "Error getting coverage.", http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := getTemplate("coverage",
+			"coverage",
+		)
+		if err != nil {
+			klog.Errorf("Error getting template data %v", err)
+			http.Error(w, "Error getting template data", http.StatusInternalServerError)
+			return
+		}
+
+		writeTemplate(tmpl, opts, &coverage, newPageRefresh(opts, r, refreshed), w)
+	})
+}
+
+// newCoverageSummarizer returns a Summarizer for the coverage of every workload in the cluster, with the dashboard options
+func newCoverageSummarizer(opts Options) *summary.Summarizer {
+	return summary.NewSummarizer(
+		summary.ForVPAsWithLabels(opts.vpaLabels),
+		summary.ForManagedNamespaces(opts.onByDefault, opts.includeNamespaces, opts.excludeNamespaces),
+		summary.WithCache(opts.cache),
+	)
+}
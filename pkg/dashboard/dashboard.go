@@ -32,15 +32,11 @@ func Dashboard(opts Options) http.Handler {
 		if val, ok := vars["namespace"]; ok {
 			namespace = val
 		}
+		if refreshPage(w, r, opts, cacheKey{kind: summaryKind, namespace: namespace}) {
+			return
+		}
 
-		// TODO [hkatz] add caching or refresh button support
-		summarizer := summary.NewSummarizer(
-			summary.ForNamespace(namespace),
-			summary.ForVPAsWithLabels(opts.vpaLabels),
-			summary.ExcludeContainers(opts.excludedContainers),
-		)
-
-		vpaData, err := summarizer.GetSummary()
+		vpaData, refreshed, err := opts.summaries.summary(namespace, false)
 		if err != nil {
 			klog.Errorf("Error getting vpaData: %v", err)
 			http.Error(w, "Error running summary.", http.StatusInternalServerError)
@@ -58,6 +54,20 @@ func Dashboard(opts Options) http.Handler {
 			return
 		}
 
-		writeTemplate(tmpl, opts, &vpaData, w)
+		writeTemplate(tmpl, opts, &vpaData, newPageRefresh(opts, r, refreshed), w)
 	})
 }
+
+// newSummarizer returns a Summarizer for the namespace, or all namespaces when it is empty, with the dashboard options
+func newSummarizer(opts Options, namespace string, setters ...summary.Option) *summary.Summarizer {
+	return summary.NewSummarizer(append([]summary.Option{
+		summary.ForNamespace(namespace),
+		summary.ForVPAsWithLabels(opts.vpaLabels),
+		summary.ExcludeContainers(opts.excludedContainers),
+		summary.WithPricing(opts.pricing),
+		summary.WithRounding(opts.rounding),
+		summary.WithRecommendationPolicy(opts.policy),
+		summary.WithCache(opts.cache),
+		summary.WithEfficiency(opts.efficiency),
+	}, setters...)...)
+}
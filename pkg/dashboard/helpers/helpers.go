@@ -18,6 +18,9 @@ import (
 	uuid "github.com/satori/go.uuid"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
 )
 
 func PrintResource(quant resource.Quantity) string {
@@ -85,9 +88,8 @@ func GetStatusRange(existing, lower, upper resource.Quantity, style string) stri
 		}
 	}
 
-	comparisonLower := existing.Cmp(lower)
-	comparisonUpper := existing.Cmp(upper)
-	if comparisonUpper <= 0 && comparisonLower >= 0 {
+	comparison := utils.CompareRange(existing, lower, upper)
+	if comparison == 0 {
 		switch style {
 		case "text":
 			return "equal"
@@ -98,7 +100,7 @@ func GetStatusRange(existing, lower, upper resource.Quantity, style string) stri
 		}
 	}
 
-	if comparisonLower < 0 {
+	if comparison < 0 {
 		switch style {
 		case "text":
 			return "less than"
@@ -107,7 +109,7 @@ func GetStatusRange(existing, lower, upper resource.Quantity, style string) stri
 		}
 	}
 
-	if comparisonUpper > 0 {
+	if comparison > 0 {
 		switch style {
 		case "text":
 			return "greater than"
@@ -119,6 +121,28 @@ func GetStatusRange(existing, lower, upper resource.Quantity, style string) stri
 	return ""
 }
 
+// MilliValue returns the quantity in thousandths, for comparing quantities in the browser
+func MilliValue(quant resource.Quantity) int64 {
+	return quant.MilliValue()
+}
+
+// NamespaceReason returns a description of why a namespace is in the namespace list, for the reasons of
+// summary.ManagedNamespace, or the reason itself when it is not known
+func NamespaceReason(reason string) string {
+	switch reason {
+	case summary.ReasonNamespaceEnabled:
+		return "enabled by label"
+	case summary.ReasonNamespaceIncluded:
+		return "included by the controller"
+	case summary.ReasonOnByDefault:
+		return "on by default"
+	case summary.ReasonGoldilocksVPAs:
+		return "has goldilocks VPAs, but is not enabled"
+	default:
+		return reason
+	}
+}
+
 func ResourceName(name string) corev1.ResourceName {
 	return corev1.ResourceName(name)
 }
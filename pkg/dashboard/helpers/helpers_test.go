@@ -20,6 +20,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
 )
 
 func Test_GetUUID(t *testing.T) {
@@ -250,3 +252,17 @@ func Test_GetStatusRange(t *testing.T) {
 		})
 	}
 }
+
+func Test_MilliValue(t *testing.T) {
+	assert.Equal(t, int64(1500), MilliValue(resource.MustParse("1500m")))
+	assert.Equal(t, int64(-250), MilliValue(resource.MustParse("-250m")))
+	assert.Equal(t, int64(0), MilliValue(resource.Quantity{}))
+}
+
+func Test_NamespaceReason(t *testing.T) {
+	assert.Equal(t, "enabled by label", NamespaceReason(summary.ReasonNamespaceEnabled))
+	assert.Equal(t, "included by the controller", NamespaceReason(summary.ReasonNamespaceIncluded))
+	assert.Equal(t, "on by default", NamespaceReason(summary.ReasonOnByDefault))
+	assert.Equal(t, "has goldilocks VPAs, but is not enabled", NamespaceReason(summary.ReasonGoldilocksVPAs))
+	assert.Equal(t, "Unknown", NamespaceReason("Unknown"))
+}
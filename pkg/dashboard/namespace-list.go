@@ -1,31 +1,39 @@
 package dashboard
 
 import (
-	"context"
 	"net/http"
 
-	"github.com/fairwindsops/goldilocks/pkg/kube"
-	"github.com/fairwindsops/goldilocks/pkg/utils"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
 )
 
-// NamespaceList replies with the rendered namespace list of all goldilocks enabled namespaces
+// NamespaceList replies with the rendered namespace list of the namespaces that the controller manages, with the same
+// rules as the controller, and those that have goldilocks VPAs
 func NamespaceList(opts Options) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		namespacesList, err := kube.GetInstance().Client.CoreV1().Namespaces().List(context.TODO(), v1.ListOptions{
-			LabelSelector: labels.Set(map[string]string{
-				utils.VpaEnabledLabel: "true",
-			}).String(),
-		})
+		if refreshPage(w, r, opts, cacheKey{kind: namespacesKind}, cacheKey{kind: summaryKind}) {
+			return
+		}
+
+		namespaces, refreshed, err := opts.summaries.namespaces(false)
 		if err != nil {
 			klog.Errorf("Error getting namespace list: %v", err)
 			http.Error(w, "Error getting namespace list", http.StatusInternalServerError)
+			return
+		}
+
+		// efficiency grades of each namespace, which are left out if the summary fails
+		vpaData, summarized, err := opts.summaries.summary("", false)
+		if err != nil {
+			klog.Errorf("Error getting vpaData for efficiency grades: %v", err)
+		} else if summarized.Before(refreshed) {
+			refreshed = summarized
 		}
 
 		tmpl, err := getTemplate("namespace_list",
 			"namespace_list",
+			"namespace",
 		)
 		if err != nil {
 			klog.Errorf("Error getting template data: %v", err)
@@ -38,18 +46,27 @@ func NamespaceList(opts Options) http.Handler {
 		// annotations, labels, metadata about the Namespace to the
 		// client UI source code or javascript console
 		data := []struct {
-			Name string
+			Name       string
+			Reason     string
+			Workloads  int
+			Efficiency *summary.Efficiency
 		}{}
 
-		for _, ns := range namespacesList.Items {
+		for _, ns := range namespaces {
 			item := struct {
-				Name string
+				Name       string
+				Reason     string
+				Workloads  int
+				Efficiency *summary.Efficiency
 			}{
-				Name: ns.Name,
+				Name:       ns.Namespace,
+				Reason:     ns.Reason,
+				Workloads:  ns.Workloads,
+				Efficiency: vpaData.Namespaces[ns.Namespace].Efficiency,
 			}
 			data = append(data, item)
 		}
 
-		writeTemplate(tmpl, opts, &data, w)
+		writeTemplate(tmpl, opts, &data, newPageRefresh(opts, r, refreshed), w)
 	})
 }
@@ -1,6 +1,10 @@
 package dashboard
 
 import (
+	"time"
+
+	"github.com/fairwindsops/goldilocks/pkg/dashboard/auth"
+	"github.com/fairwindsops/goldilocks/pkg/summary"
 	"github.com/fairwindsops/goldilocks/pkg/utils"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -14,6 +18,19 @@ type Options struct {
 	basePath           string
 	vpaLabels          map[string]string
 	excludedContainers sets.String
+	pricing            *summary.Pricing
+	rounding           utils.RoundingPolicy
+	policy             *summary.RecommendationPolicy
+	cache              *summary.Cache
+	efficiency         *summary.EfficiencyPolicy
+	onByDefault        bool
+	includeNamespaces  []string
+	excludeNamespaces  []string
+	refreshInterval    time.Duration
+	authenticators     []auth.Authenticator
+
+	// summaries caches the summaries of the dashboard, and is created by GetRouter
+	summaries *summaryCache
 }
 
 // default options for the dashboard
@@ -23,6 +40,7 @@ func defaultOptions() *Options {
 		basePath:           "/",
 		vpaLabels:          utils.VPALabels,
 		excludedContainers: sets.NewString(),
+		refreshInterval:    time.Minute,
 	}
 }
 
@@ -53,3 +71,61 @@ func ForVPAsWithLabels(vpaLabels map[string]string) Option {
 		opts.vpaLabels = vpaLabels
 	}
 }
+
+// Option for estimating costs in the dashboard summary
+func WithPricing(pricing *summary.Pricing) Option {
+	return func(opts *Options) {
+		opts.pricing = pricing
+	}
+}
+
+// Option for rounding the suggested values in the dashboard summary
+func WithRounding(policy utils.RoundingPolicy) Option {
+	return func(opts *Options) {
+		opts.rounding = policy
+	}
+}
+
+// Option for suggesting requests and limits calculated by a recommendation policy in the dashboard summary
+func WithRecommendationPolicy(policy *summary.RecommendationPolicy) Option {
+	return func(opts *Options) {
+		opts.policy = policy
+	}
+}
+
+// Option for reading the dashboard summary from a started informer cache instead of listing objects for each request
+func WithCache(cache *summary.Cache) Option {
+	return func(opts *Options) {
+		opts.cache = cache
+	}
+}
+
+// Option for the weights and grades of the efficiency scores in the dashboard summary
+func WithEfficiency(policy *summary.EfficiencyPolicy) Option {
+	return func(opts *Options) {
+		opts.efficiency = policy
+	}
+}
+
+// Option for the namespaces that the controller manages in the coverage report
+func ForManagedNamespaces(onByDefault bool, includeNamespaces, excludeNamespaces []string) Option {
+	return func(opts *Options) {
+		opts.onByDefault = onByDefault
+		opts.includeNamespaces = includeNamespaces
+		opts.excludeNamespaces = excludeNamespaces
+	}
+}
+
+// Option for how long the dashboard serves a summary before refreshing it, which disables caching summaries when it is 0
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(opts *Options) {
+		opts.refreshInterval = interval
+	}
+}
+
+// Option for requiring requests to the dashboard to be authenticated by one of the authenticators, in order
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(opts *Options) {
+		opts.authenticators = authenticators
+	}
+}
@@ -19,6 +19,9 @@ import (
 
 	packr "github.com/gobuffalo/packr/v2"
 	"github.com/gorilla/mux"
+
+	"github.com/fairwindsops/goldilocks/pkg/dashboard/auth"
+	"github.com/fairwindsops/goldilocks/pkg/summary"
 )
 
 var (
@@ -39,6 +42,17 @@ func GetRouter(setters ...Option) *mux.Router {
 	for _, setter := range setters {
 		setter(opts)
 	}
+	opts.summaries = newSummaryCache(opts.refreshInterval,
+		func(namespace string) (summary.Summary, error) {
+			return newSummarizer(*opts, namespace).GetSummary()
+		},
+		func() (summary.Coverage, error) {
+			return newCoverageSummarizer(*opts).GetCoverage()
+		},
+		func() ([]summary.ManagedNamespace, error) {
+			return newSummarizer(*opts, "", summary.ForManagedNamespaces(opts.onByDefault, opts.includeNamespaces, opts.excludeNamespaces)).GetManagedNamespaces()
+		},
+	)
 
 	router := mux.NewRouter()
 
@@ -50,15 +64,39 @@ func GetRouter(setters ...Option) *mux.Router {
 	router.Handle("/favicon.ico", Asset("/images/favicon-32x32.png"))
 	router.PathPrefix("/static/").Handler(StaticAssets("/static/"))
 
+	// login callback, which is reached before the user is authenticated
+	for _, authenticator := range opts.authenticators {
+		if login, ok := authenticator.(auth.Login); ok {
+			router.Handle(auth.CallbackPath, login.Callback())
+			break
+		}
+	}
+
+	// everything else requires authentication when there are authenticators
+	protected := router.PathPrefix("/").Subrouter()
+	if len(opts.authenticators) > 0 {
+		protected.Use(auth.Middleware(opts.basePath, opts.authenticators...))
+	}
+
 	// dashboard
-	router.Handle("/dashboard", Dashboard(*opts))
-	router.Handle("/dashboard/{namespace:[a-zA-Z0-9-]+}", Dashboard(*opts))
+	protected.Handle("/dashboard", Dashboard(*opts)).Methods(http.MethodGet, http.MethodPost)
+	protected.Handle("/dashboard/{namespace:[a-zA-Z0-9-]+}", Dashboard(*opts)).Methods(http.MethodGet, http.MethodPost)
 
 	// namespace list
-	router.Handle("/namespaces", NamespaceList(*opts))
+	protected.Handle("/namespaces", NamespaceList(*opts)).Methods(http.MethodGet, http.MethodPost)
+
+	// coverage of every workload
+	protected.Handle("/coverage", Coverage(*opts)).Methods(http.MethodGet, http.MethodPost)
+
+	// versioned JSON API
+	api := protected.PathPrefix(APIPathPrefix).Subrouter()
+	api.Handle("/namespaces", APINamespaces(*opts)).Methods(http.MethodGet)
+	api.Handle("/summary", APISummary(*opts)).Methods(http.MethodGet, http.MethodPost)
+	api.Handle("/namespaces/{namespace:[a-zA-Z0-9-]+}/summary", APISummary(*opts)).Methods(http.MethodGet, http.MethodPost)
+	api.Handle("/namespaces/{namespace:[a-zA-Z0-9-]+}/workloads/{name:[a-zA-Z0-9-.]+}", APIWorkload(*opts)).Methods(http.MethodGet)
 
 	// root
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	protected.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// catch all other paths that weren't matched
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
@@ -0,0 +1,183 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/summary"
+)
+
+// cacheKind is the kind of data that a summaryCache entry holds
+type cacheKind int
+
+const (
+	// summaryKind is the Summary of a namespace, or of all namespaces
+	summaryKind cacheKind = iota
+	// coverageKind is the Coverage of every workload in the cluster
+	coverageKind
+	// namespacesKind is the list of managed namespaces
+	namespacesKind
+)
+
+// cacheKey identifies an entry of a summaryCache by its kind, and the namespace of a summary
+type cacheKey struct {
+	kind      cacheKind
+	namespace string
+}
+
+// String returns the key as it is logged
+func (k cacheKey) String() string {
+	switch k.kind {
+	case coverageKind:
+		return "coverage report"
+	case namespacesKind:
+		return "namespace list"
+	}
+	return "summary of namespace " + strconv.Quote(k.namespace)
+}
+
+// summaryCache caches the summary of each namespace, of all namespaces, the coverage report and the namespace list, so
+// that pages are not
+// summarized on every request. A summary older than the refresh interval is still served while it is refreshed in the
+// background. Requests for a summary that is already being summarized wait for it instead of summarizing it again.
+// Cached summaries are shared between requests, so they must not be changed.
+type summaryCache struct {
+	interval  time.Duration
+	summarize func(namespace string) (summary.Summary, error)
+	cover     func() (summary.Coverage, error)
+	list      func() ([]summary.ManagedNamespace, error)
+
+	mu       sync.Mutex
+	entries  map[cacheKey]*cachedSummary
+	inFlight map[cacheKey]*summaryCall
+}
+
+// cachedSummary is the Summary, the Coverage or the namespaces of a cacheKey, whichever its kind is, and when it was
+// created
+type cachedSummary struct {
+	summary    summary.Summary
+	coverage   summary.Coverage
+	namespaces []summary.ManagedNamespace
+	refreshed  time.Time
+}
+
+// summaryCall is a summarization in progress, whose result is set before done is closed
+type summaryCall struct {
+	done chan struct{}
+
+	entry cachedSummary
+	err   error
+}
+
+// newSummaryCache returns a summaryCache that refreshes summaries older than the interval, or that does not cache
+// summaries at all when the interval is not positive
+func newSummaryCache(interval time.Duration, summarize func(namespace string) (summary.Summary, error), cover func() (summary.Coverage, error), list func() ([]summary.ManagedNamespace, error)) *summaryCache {
+	return &summaryCache{
+		interval:  interval,
+		summarize: summarize,
+		cover:     cover,
+		list:      list,
+		entries:   map[cacheKey]*cachedSummary{},
+		inFlight:  map[cacheKey]*summaryCall{},
+	}
+}
+
+// summary returns the Summary of the namespace, or all namespaces when it is empty, and when it was created
+func (c *summaryCache) summary(namespace string, refresh bool) (summary.Summary, time.Time, error) {
+	cached, err := c.get(cacheKey{kind: summaryKind, namespace: namespace}, refresh)
+	return cached.summary, cached.refreshed, err
+}
+
+// coverage returns the Coverage of every workload in the cluster, and when it was created
+func (c *summaryCache) coverage(refresh bool) (summary.Coverage, time.Time, error) {
+	cached, err := c.get(cacheKey{kind: coverageKind}, refresh)
+	return cached.coverage, cached.refreshed, err
+}
+
+// namespaces returns the managed namespaces, and when they were listed
+func (c *summaryCache) namespaces(refresh bool) ([]summary.ManagedNamespace, time.Time, error) {
+	cached, err := c.get(cacheKey{kind: namespacesKind}, refresh)
+	return cached.namespaces, cached.refreshed, err
+}
+
+// get returns the entry with the key. It waits for the key to be summarized when it is not cached or refresh is set,
+// and otherwise refreshes it in the background when it is stale. Concurrent requests share a single summarization of
+// the key.
+func (c *summaryCache) get(key cacheKey, refresh bool) (cachedSummary, error) {
+	c.mu.Lock()
+	if cached, ok := c.entries[key]; ok && !refresh && c.interval > 0 {
+		if time.Since(cached.refreshed) >= c.interval {
+			c.refresh(key)
+		}
+		entry := *cached
+		c.mu.Unlock()
+		return entry, nil
+	}
+	call := c.refresh(key)
+	c.mu.Unlock()
+
+	<-call.done
+	return call.entry, call.err
+}
+
+// refresh starts summarizing the key in the background, unless it already is, and returns the summarization in
+// progress. The entry is cached when it succeeds, and the previous one is kept when it fails. c.mu must be held.
+func (c *summaryCache) refresh(key cacheKey) *summaryCall {
+	if call, ok := c.inFlight[key]; ok {
+		return call
+	}
+	call := &summaryCall{done: make(chan struct{})}
+	c.inFlight[key] = call
+
+	go func() {
+		klog.V(3).Infof("Refreshing %v", key)
+		entry, err := c.create(key)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.inFlight, key)
+		call.entry, call.err = entry, err
+		close(call.done)
+		if err != nil {
+			klog.Errorf("Error refreshing %v: %v", key, err)
+			return
+		}
+		if c.interval > 0 {
+			c.entries[key] = &entry
+		}
+	}()
+	return call
+}
+
+// create returns a new entry with the data of the kind of the key
+func (c *summaryCache) create(key cacheKey) (cachedSummary, error) {
+	var entry cachedSummary
+	var err error
+	switch key.kind {
+	case coverageKind:
+		entry.coverage, err = c.cover()
+	case namespacesKind:
+		entry.namespaces, err = c.list()
+	default:
+		entry.summary, err = c.summarize(key.namespace)
+	}
+	entry.refreshed = time.Now()
+	return entry, err
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"path"
+	"time"
 
 	"github.com/fairwindsops/goldilocks/pkg/dashboard/helpers"
+	"github.com/fairwindsops/goldilocks/pkg/summary"
 	"github.com/gobuffalo/packr/v2"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/klog"
 )
 
@@ -24,6 +29,7 @@ const (
 	ContainerTemplateName    = "container.gohtml"
 	FooterTemplateName       = "footer.gohtml"
 	CheckDetailsTemplateName = "check-details.gohtml"
+	CoverageTemplateName     = "coverage.gohtml"
 )
 
 var (
@@ -46,6 +52,43 @@ type baseTemplateData struct {
 
 	// JSON is the json version of Data
 	JSON template.JS
+
+	// Refresh is when Data was last refreshed, for pages whose data is cached
+	Refresh *pageRefresh
+}
+
+// pageRefresh is when the cached data of a page was last refreshed, and the URL to post to for refreshing it now
+type pageRefresh struct {
+	Refreshed time.Time
+	URL       string
+}
+
+// newPageRefresh returns the pageRefresh of the requested page, whose data was refreshed at the time
+func newPageRefresh(opts Options, r *http.Request, refreshed time.Time) *pageRefresh {
+	return &pageRefresh{
+		Refreshed: refreshed,
+		URL:       pageURL(opts, r),
+	}
+}
+
+// refreshPage summarizes the cached data of the page with the keys again when the page is posted to, and then
+// redirects back to the page. It returns whether it replied, which it does not to requests of any other method.
+func refreshPage(w http.ResponseWriter, r *http.Request, opts Options, keys ...cacheKey) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	// a failed refresh is logged by the cache, and the page shows the previous summary
+	for _, key := range keys {
+		_, _ = opts.summaries.get(key, true)
+	}
+	http.Redirect(w, r, pageURL(opts, r), http.StatusSeeOther)
+	return true
+}
+
+// pageURL returns the URL of the requested page with its query parameters
+func pageURL(opts Options, r *http.Request) string {
+	u := url.URL{Path: path.Join(opts.basePath, r.URL.Path), RawQuery: r.URL.RawQuery}
+	return u.String()
 }
 
 // getTemplateBox returns a binary-friendly set of templates for rendering the dash
@@ -59,11 +102,16 @@ func getTemplateBox() *packr.Box {
 // getTemplate puts together a template. Individual pieces can be overridden before rendering.
 func getTemplate(name string, includedTemplates ...string) (*template.Template, error) {
 	tmpl := template.New(name).Funcs(template.FuncMap{
-		"printResource":  helpers.PrintResource,
-		"getStatus":      helpers.GetStatus,
-		"getStatusRange": helpers.GetStatusRange,
-		"resourceName":   helpers.ResourceName,
-		"getUUID":        helpers.GetUUID,
+		"printResource":   helpers.PrintResource,
+		"getStatus":       helpers.GetStatus,
+		"getStatusRange":  helpers.GetStatusRange,
+		"resourceName":    helpers.ResourceName,
+		"getUUID":         helpers.GetUUID,
+		"printDelta":      summary.DeltaString,
+		"printSize":       summary.SizeString,
+		"milliValue":      helpers.MilliValue,
+		"timeSince":       timeSince,
+		"namespaceReason": helpers.NamespaceReason,
 	})
 
 	// join the default templates and included templates
@@ -93,7 +141,7 @@ func parseTemplateFiles(tmpl *template.Template, includedTemplates []string) (*t
 }
 
 // writeTemplate executes the given template with the data and writes to the writer.
-func writeTemplate(tmpl *template.Template, opts Options, data interface{}, w http.ResponseWriter) {
+func writeTemplate(tmpl *template.Template, opts Options, data interface{}, refresh *pageRefresh, w http.ResponseWriter) {
 	buf := &bytes.Buffer{}
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -104,6 +152,7 @@ func writeTemplate(tmpl *template.Template, opts Options, data interface{}, w ht
 		BasePath: opts.basePath,
 		Data:     data,
 		JSON:     template.JS(jsonData),
+		Refresh:  refresh,
 	})
 	if err != nil {
 		klog.Errorf("Error executing template: %v", err)
@@ -115,3 +164,8 @@ func writeTemplate(tmpl *template.Template, opts Options, data interface{}, w ht
 		klog.Errorf("Error writing template: %v", err)
 	}
 }
+
+// timeSince returns how long ago the time was, in the same format as kubectl, e.g. "5m"
+func timeSince(t time.Time) string {
+	return duration.HumanDuration(time.Since(t))
+}
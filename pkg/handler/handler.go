@@ -19,6 +19,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/klog"
 
 	"github.com/fairwindsops/goldilocks/pkg/utils"
@@ -40,6 +41,8 @@ func OnUpdate(obj interface{}, event utils.Event) {
 		OnDeploymentChanged(obj.(*appsv1.Deployment), event)
 	case *corev1.Namespace:
 		OnNamespaceChanged(obj.(*corev1.Namespace), event)
+	case *vpav1.VerticalPodAutoscaler:
+		OnVPAChanged(obj.(*vpav1.VerticalPodAutoscaler), event)
 	default:
 		klog.Errorf("Object has unknown type of %T", t)
 	}
@@ -52,6 +55,8 @@ func onDelete(event utils.Event) {
 		OnNamespaceChanged(&corev1.Namespace{}, event)
 	case "deployment":
 		OnDeploymentChanged(&appsv1.Deployment{}, event)
+	case "vpa":
+		OnVPAChanged(&vpav1.VerticalPodAutoscaler{}, event)
 	default:
 		klog.Errorf("object has unknown resource type %s", event.ResourceType)
 	}
@@ -0,0 +1,41 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"strings"
+
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+	"github.com/fairwindsops/goldilocks/pkg/vpa"
+)
+
+// OnVPAChanged is a handler that should be called when a vpa changes.
+func OnVPAChanged(verticalPodAutoscaler *vpav1.VerticalPodAutoscaler, event utils.Event) {
+	switch strings.ToLower(event.EventType) {
+	case "delete":
+		klog.V(3).Infof("VPA %s deleted. Nothing to annotate.", event.Key)
+	case "create", "update":
+		klog.V(3).Infof("VPA %s updated. Annotate its workload.", verticalPodAutoscaler.ObjectMeta.Name)
+		err := vpa.GetInstance().AnnotateDeployment(verticalPodAutoscaler)
+		if err != nil {
+			klog.Errorf("Error annotating: %v", err)
+		}
+	default:
+		klog.V(3).Infof("Update type %s is not valid, skipping.", event.EventType)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	vpafake "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog"
+)
+
+// manifestExtensions are the extensions of the files read from directories
+var manifestExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// GetFileClients returns clients that serve the objects in YAML or JSON manifest files instead of a cluster,
+// such as the output of `kubectl get -o yaml` or `kubectl cluster-info dump`. Directories are read recursively
+// for files with a .yaml, .yml or .json extension. Lists are expanded into their items, and objects of kinds
+// that are not built in to Kubernetes, other than VerticalPodAutoscalers, are ignored.
+func GetFileClients(paths []string) (*ClientInstance, *VPAClientInstance, error) {
+	kubeClientset := fake.NewSimpleClientset()
+	vpaClientset := vpafake.NewSimpleClientset()
+
+	for _, file := range manifestFiles(paths) {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects, err := decodeManifests(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		for _, obj := range objects {
+			if _, ok := obj.(*vpav1.VerticalPodAutoscaler); ok {
+				err = vpaClientset.Tracker().Add(obj)
+			} else {
+				err = kubeClientset.Tracker().Add(obj)
+			}
+			if apierrors.IsAlreadyExists(err) {
+				// The same object is often in more than one file, such as a manifest and a dump, so keep the first one
+				if accessor, aerr := meta.Accessor(obj); aerr == nil {
+					klog.V(3).Infof("Skipping duplicate %s %s/%s in %s", obj.GetObjectKind().GroupVersionKind().Kind, accessor.GetNamespace(), accessor.GetName(), file)
+				}
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("error reading %s: %v", file, err)
+			}
+		}
+	}
+
+	return &ClientInstance{Client: kubeClientset}, &VPAClientInstance{Client: vpaClientset}, nil
+}
+
+// manifestFiles returns the files at the paths, and the manifest files in the directories at the paths.
+// Paths that cannot be read are returned as they are, so that reading them reports the error.
+func manifestFiles(paths []string) []string {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && manifestExtensions[strings.ToLower(filepath.Ext(file))] {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// decodeManifests decodes each of the YAML or JSON documents in the data into a typed object
+func decodeManifests(data []byte) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		raw := json.RawMessage{}
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(raw)) <= 0 || string(raw) == "null" {
+			continue
+		}
+
+		decoded, err := decodeManifest(raw, schema.GroupVersionKind{})
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, decoded...)
+	}
+	return objects, nil
+}
+
+// decodeManifest decodes a single object, or the items of a list. Items without a kind, such as those in the
+// typed lists of `kubectl cluster-info dump`, get the kind of their list.
+func decodeManifest(raw []byte, listKind schema.GroupVersionKind) ([]runtime.Object, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	gvk := typeMeta.GroupVersionKind()
+	if gvk.Kind == "" {
+		gvk = listKind
+		if gvk.Kind == "" {
+			return nil, fmt.Errorf("object without a kind")
+		}
+		typeMeta.APIVersion, typeMeta.Kind = gvk.ToAPIVersionAndKind()
+		if err := setTypeMeta(&raw, typeMeta); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.HasSuffix(gvk.Kind, "List") {
+		list := struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		itemKind := gvk.GroupVersion().WithKind(strings.TrimSuffix(gvk.Kind, "List"))
+		objects := []runtime.Object{}
+		for _, item := range list.Items {
+			decoded, err := decodeManifest(item, itemKind)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, decoded...)
+		}
+		return objects, nil
+	}
+
+	// the v1beta2 and v1 VPA APIs are the same, so both are read as v1
+	if gvk.Group == vpav1.SchemeGroupVersion.Group && gvk.Kind == "VerticalPodAutoscaler" {
+		vpa := &vpav1.VerticalPodAutoscaler{}
+		if err := json.Unmarshal(raw, vpa); err != nil {
+			return nil, err
+		}
+		vpa.APIVersion = vpav1.SchemeGroupVersion.String()
+		return []runtime.Object{vpa}, nil
+	}
+
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		klog.V(3).Infof("Ignoring object of unsupported kind %s", gvk)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return []runtime.Object{obj}, nil
+}
+
+// setTypeMeta sets the apiVersion and kind of the raw object
+func setTypeMeta(raw *[]byte, typeMeta metav1.TypeMeta) error {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(*raw, &obj); err != nil {
+		return err
+	}
+	obj["apiVersion"] = typeMeta.APIVersion
+	obj["kind"] = typeMeta.Kind
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	*raw = data
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testManifests = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: demo
+- apiVersion: autoscaling.k8s.io/v1beta2
+  kind: VerticalPodAutoscaler
+  metadata:
+    name: web
+    namespace: demo
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: ignored
+`
+
+// testDump is a typed list as written by kubectl cluster-info dump, with items that have no kind
+const testDump = `{
+  "kind": "DeploymentList",
+  "apiVersion": "apps/v1",
+  "items": [
+    {"metadata": {"name": "worker", "namespace": "demo"}}
+  ]
+}`
+
+func TestGetFileClients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldilocks-files")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "demo"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "manifests.yaml"), []byte(testManifests), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "demo", "deployments.json"), []byte(testDump), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "demo", "pod.log"), []byte("not a manifest"), 0644))
+
+	kubeClient, vpaClient, err := GetFileClients([]string{dir})
+	assert.NoError(t, err)
+
+	deployments, err := kubeClient.Client.AppsV1().Deployments("demo").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	names := []string{}
+	for _, d := range deployments.Items {
+		names = append(names, d.Name)
+	}
+	assert.ElementsMatch(t, []string{"web", "worker"}, names)
+
+	_, err = kubeClient.Client.CoreV1().Namespaces().Get(context.TODO(), "demo", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	vpas, err := vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers("demo").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, vpas.Items, 1)
+
+	// Objects in more than one file are kept once
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "copy.yaml"), []byte(testManifests), 0644))
+	kubeClient, vpaClient, err = GetFileClients([]string{filepath.Join(dir, "manifests.yaml"), filepath.Join(dir, "copy.yaml")})
+	assert.NoError(t, err)
+	deployments, err = kubeClient.Client.AppsV1().Deployments("demo").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, deployments.Items, 1)
+	vpas, err = vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers("demo").List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, vpas.Items, 1)
+
+	_, _, err = GetFileClients([]string{filepath.Join(dir, "missing.yaml")})
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("items:\n- metadata: {}\n"), 0644))
+	_, _, err = GetFileClients([]string{filepath.Join(dir, "invalid.yaml")})
+	assert.EqualError(t, err, "error reading "+filepath.Join(dir, "invalid.yaml")+": object without a kind")
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	vpainformers "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/informers/externalversions"
+	vpalisters "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/listers/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+)
+
+// Cache is a shared informer cache of the objects that are summarized, for long running servers such as the dashboard.
+// Summarizers that use it read from the cache instead of listing every object from the API server for each summary.
+type Cache struct {
+	kubeInformers informers.SharedInformerFactory
+	vpaInformers  vpainformers.SharedInformerFactory
+
+	// the VPAs and Deployments that are summarized
+	vpas        vpalisters.VerticalPodAutoscalerLister
+	deployments appslisters.DeploymentLister
+	// the Namespaces whose annotations set recommendation policies, and that are listed for coverage
+	namespaces corelisters.NamespaceLister
+	// the LimitRanges and ResourceQuotas that the suggested values are checked against
+	limitRanges    corelisters.LimitRangeLister
+	resourceQuotas corelisters.ResourceQuotaLister
+}
+
+// NewCache returns a Cache of the objects in all namespaces, which is filled once it is started
+func NewCache(kubeClient *kube.ClientInstance, vpaClient *kube.VPAClientInstance, resync time.Duration) *Cache {
+	c := &Cache{
+		kubeInformers: informers.NewSharedInformerFactory(kubeClient.Client, resync),
+		vpaInformers:  vpainformers.NewSharedInformerFactory(vpaClient.Client, resync),
+	}
+
+	// getting each lister registers its informer with the factory, so that starting the factory starts it
+	c.vpas = c.vpaInformers.Autoscaling().V1().VerticalPodAutoscalers().Lister()
+	c.deployments = c.kubeInformers.Apps().V1().Deployments().Lister()
+	c.namespaces = c.kubeInformers.Core().V1().Namespaces().Lister()
+	c.limitRanges = c.kubeInformers.Core().V1().LimitRanges().Lister()
+	c.resourceQuotas = c.kubeInformers.Core().V1().ResourceQuotas().Lister()
+	return c
+}
+
+// Start starts watching the objects until the stop channel is closed, and waits up to the timeout for the initial lists
+// to be cached, or without a timeout if it is 0. It returns an error naming the objects that did not sync in time, such as
+// those that cannot be listed, in which case the stop channel should be closed and the objects listed without the cache.
+func (c *Cache) Start(stopCh <-chan struct{}, timeout time.Duration) error {
+	klog.V(3).Info("Starting informer cache")
+	c.kubeInformers.Start(stopCh)
+	c.vpaInformers.Start(stopCh)
+
+	waitCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(waitCh)
+		var timeoutCh <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timeoutCh = timer.C
+		}
+		select {
+		case <-stopCh:
+		case <-timeoutCh:
+		case <-done:
+		}
+	}()
+
+	notSynced := []string{}
+	for informerType, ok := range c.kubeInformers.WaitForCacheSync(waitCh) {
+		if !ok {
+			notSynced = append(notSynced, informerType.String())
+		}
+	}
+	for informerType, ok := range c.vpaInformers.WaitForCacheSync(waitCh) {
+		if !ok {
+			notSynced = append(notSynced, informerType.String())
+		}
+	}
+	if len(notSynced) > 0 {
+		sort.Strings(notSynced)
+		return fmt.Errorf("timed out waiting for the %s caches to sync", strings.Join(notSynced, ", "))
+	}
+	klog.V(3).Info("Informer cache synced")
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// createTestWorkload creates a Deployment with a goldilocks VPA that recommends 1000000Ki of memory, which the summary formats as 1953125Ki
+func createTestWorkload(t *testing.T, kubeClient *kube.ClientInstance, vpaClient *kube.VPAClientInstance, namespace, name string) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+		},
+	}
+	vpa := &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: utils.VPALabels},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+		},
+		Status: vpav1.VerticalPodAutoscalerStatus{
+			Recommendation: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{{
+					ContainerName: "app",
+					Target:        corev1.ResourceList{"memory": resource.MustParse("1000000Ki")},
+				}},
+			},
+		},
+	}
+	_, err := kubeClient.Client.AppsV1().Deployments(namespace).Create(context.TODO(), deployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers(namespace).Create(context.TODO(), vpa, metav1.CreateOptions{})
+	assert.NoError(t, err)
+}
+
+func TestSummarizerWithCache(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+	createTestWorkload(t, kubeClient, kubeClientVPA, "cached", "app")
+	createTestWorkload(t, kubeClient, kubeClientVPA, "other", "app")
+
+	cache := NewCache(kubeClient, kubeClientVPA, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	assert.NoError(t, cache.Start(stopCh, time.Minute))
+
+	for _, namespace := range []string{"", "cached"} {
+		summarizer := NewSummarizer(ForNamespace(namespace), WithCache(cache))
+		got, err := summarizer.GetSummary()
+		assert.NoError(t, err)
+		if assert.Contains(t, got.Namespaces, "cached") {
+			target := got.Namespaces["cached"].Deployments["app"].Containers["app"].Target
+			assert.Equal(t, "1953125Ki", target.Memory().String())
+		}
+		assert.Equal(t, namespace == "", len(got.Namespaces) == 2)
+	}
+
+	// summarizing does not change the cached objects
+	cached, err := cache.vpas.VerticalPodAutoscalers("cached").Get("app")
+	assert.NoError(t, err)
+	target := cached.Status.Recommendation.ContainerRecommendations[0].Target
+	assert.Equal(t, "1000000Ki", target.Memory().String())
+}
+
+func TestCacheStartTimeout(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	// without RBAC to list the LimitRanges, their cache never syncs
+	clientset := kubeClient.Client.(*fake.Clientset)
+	clientset.PrependReactor("list", "limitranges", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("limitranges"), "", fmt.Errorf("not allowed"))
+	})
+
+	cache := NewCache(kubeClient, kubeClientVPA, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	started := time.Now()
+	err := cache.Start(stopCh, 500*time.Millisecond)
+	assert.EqualError(t, err, "timed out waiting for the *v1.LimitRange caches to sync")
+	assert.True(t, time.Since(started) < 10*time.Second)
+}
+
+func TestSummarizerPagination(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+	for i := 0; i < 5; i++ {
+		createTestWorkload(t, kubeClient, kubeClientVPA, "paged", fmt.Sprintf("app-%d", i))
+	}
+
+	// serve the deployments two at a time, setting a continue token on each page but the last
+	pages := 0
+	clientset := kubeClient.Client.(*fake.Clientset)
+	clientset.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		all, err := clientset.Tracker().List(appsv1.SchemeGroupVersion.WithResource("deployments"), appsv1.SchemeGroupVersion.WithKind("Deployment"), "paged")
+		if err != nil {
+			return true, nil, err
+		}
+		items := all.(*appsv1.DeploymentList).Items
+		start := pages * 2
+		end := start + 2
+		page := &appsv1.DeploymentList{}
+		if end < len(items) {
+			page.Continue = strconv.Itoa(end)
+		} else {
+			end = len(items)
+		}
+		page.Items = items[start:end]
+		pages++
+		return true, page, nil
+	})
+
+	summarizer := NewSummarizer(ForNamespace("paged"), WithPageSize(2))
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+	assert.Len(t, got.Namespaces["paged"].Deployments, 5)
+	assert.Equal(t, 3, pages)
+}
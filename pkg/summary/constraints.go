@@ -0,0 +1,244 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+)
+
+// QuotaHeadroom is how much of a resource in a ResourceQuota is left, now and after applying the guaranteed recommendations
+type QuotaHeadroom struct {
+	Name     string              `json:"name"`
+	Resource corev1.ResourceName `json:"resource"`
+	Hard     resource.Quantity   `json:"hard"`
+	Used     resource.Quantity   `json:"used"`
+
+	// Headroom is the hard limit minus what is used, and HeadroomAfter is the same after applying the recommendations.
+	// Either is negative when the namespace is over its quota.
+	Headroom      resource.Quantity `json:"headroom"`
+	HeadroomAfter resource.Quantity `json:"headroomAfter"`
+}
+
+// namespaceConstraints are the LimitRanges and ResourceQuotas of a namespace
+type namespaceConstraints struct {
+	limitRanges []corev1.LimitRange
+	quotas      []corev1.ResourceQuota
+}
+
+// quotaResources are the ResourceQuota resources that recommendations change, and whether each is of requests or limits
+var quotaResources = map[corev1.ResourceName]struct {
+	name     corev1.ResourceName
+	requests bool
+}{
+	corev1.ResourceCPU:            {corev1.ResourceCPU, true},
+	corev1.ResourceMemory:         {corev1.ResourceMemory, true},
+	corev1.ResourceRequestsCPU:    {corev1.ResourceCPU, true},
+	corev1.ResourceRequestsMemory: {corev1.ResourceMemory, true},
+	corev1.ResourceLimitsCPU:      {corev1.ResourceCPU, false},
+	corev1.ResourceLimitsMemory:   {corev1.ResourceMemory, false},
+}
+
+// constraintsFor returns the LimitRanges and ResourceQuotas of the namespace, looking them up once and caching them in the map.
+// Errors listing them are logged, and leave the namespace without that kind of constraint.
+func (s Summarizer) constraintsFor(constraints map[string]*namespaceConstraints, namespace string) *namespaceConstraints {
+	if c, ok := constraints[namespace]; ok {
+		return c
+	}
+	c := &namespaceConstraints{}
+	if s.cache != nil {
+		c.fromCache(s.cache, namespace)
+		constraints[namespace] = c
+		return c
+	}
+
+	limitRanges, err := s.kubeClient.Client.CoreV1().LimitRanges(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.V(2).Infof("Error listing LimitRanges in Namespace/%s: %v", namespace, err)
+	} else {
+		c.limitRanges = limitRanges.Items
+	}
+	quotas, err := s.kubeClient.Client.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.V(2).Infof("Error listing ResourceQuotas in Namespace/%s: %v", namespace, err)
+	} else {
+		c.quotas = quotas.Items
+	}
+	constraints[namespace] = c
+	return c
+}
+
+// fromCache sets the LimitRanges and ResourceQuotas of the namespace from the cache
+func (c *namespaceConstraints) fromCache(cache *Cache, namespace string) {
+	limitRanges, err := cache.limitRanges.LimitRanges(namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(2).Infof("Error listing LimitRanges in Namespace/%s: %v", namespace, err)
+	}
+	for _, limitRange := range limitRanges {
+		c.limitRanges = append(c.limitRanges, *limitRange)
+	}
+	quotas, err := cache.resourceQuotas.ResourceQuotas(namespace).List(labels.Everything())
+	if err != nil {
+		klog.V(2).Infof("Error listing ResourceQuotas in Namespace/%s: %v", namespace, err)
+	}
+	for _, quota := range quotas {
+		c.quotas = append(c.quotas, *quota)
+	}
+}
+
+// clamp returns a copy of the named recommendation with its requests and limits clamped to the minimum and maximum
+// of the container LimitRanges, and a warning for each value that was clamped or breaks a maximum limit to request ratio
+func (c *namespaceConstraints) clamp(name string, rec Recommendation) (Recommendation, []string) {
+	clamped := Recommendation{
+		Requests:  rec.Requests.DeepCopy(),
+		Limits:    rec.Limits.DeepCopy(),
+		Unlimited: rec.Unlimited,
+	}
+	warnings := []string{}
+	for _, limitRange := range c.limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for _, resourceName := range qosResources {
+				for _, value := range []struct {
+					kind string
+					rl   corev1.ResourceList
+				}{{"request", clamped.Requests}, {"limit", clamped.Limits}} {
+					quant, ok := value.rl[resourceName]
+					if !ok {
+						continue
+					}
+					if max, ok := item.Max[resourceName]; ok && quant.Cmp(max) > 0 {
+						warnings = append(warnings, fmt.Sprintf("%s %s %s of %s is above the maximum of %s in LimitRange/%s, so it was lowered to the maximum", name, resourceName, value.kind, quant.String(), max.String(), limitRange.Name))
+						value.rl[resourceName] = max.DeepCopy()
+					}
+					if min, ok := item.Min[resourceName]; ok && quant.Cmp(min) < 0 {
+						warnings = append(warnings, fmt.Sprintf("%s %s %s of %s is below the minimum of %s in LimitRange/%s, so it was raised to the minimum", name, resourceName, value.kind, quant.String(), min.String(), limitRange.Name))
+						value.rl[resourceName] = min.DeepCopy()
+					}
+				}
+
+				ratio, ok := item.MaxLimitRequestRatio[resourceName]
+				request, hasRequest := clamped.Requests[resourceName]
+				limit, hasLimit := clamped.Limits[resourceName]
+				if ok && hasRequest && hasLimit && request.MilliValue() > 0 &&
+					float64(limit.MilliValue())/float64(request.MilliValue()) > float64(ratio.MilliValue())/1000 {
+					warnings = append(warnings, fmt.Sprintf("%s %s limit of %s is more than %s times the request of %s, the maximum ratio in LimitRange/%s", name, resourceName, limit.String(), ratio.String(), request.String(), limitRange.Name))
+				}
+			}
+		}
+	}
+	return clamped, warnings
+}
+
+// clamp clamps the suggested requests and limits of the container to the LimitRanges, with a warning for each change
+func (cSummary *ContainerSummary) clamp(c *namespaceConstraints) {
+	var warnings []string
+	cSummary.Guaranteed, warnings = c.clamp(QoSGuaranteed, cSummary.Guaranteed)
+	cSummary.Warnings = append(cSummary.Warnings, warnings...)
+	cSummary.Burstable, warnings = c.clamp(QoSBurstable, cSummary.Burstable)
+	cSummary.Warnings = append(cSummary.Warnings, warnings...)
+	if cSummary.Policy != nil {
+		policyRecommendation, warnings := c.clamp(QoSPolicy, *cSummary.Policy)
+		cSummary.Policy = &policyRecommendation
+		cSummary.Warnings = append(cSummary.Warnings, warnings...)
+	}
+}
+
+// quotaHeadroom returns the headroom of each ResourceQuota resource that recommendations change, before and after
+// applying the guaranteed recommendations of the namespace, and a warning for each that the recommendations would exceed
+func (c *namespaceConstraints) quotaHeadroom(nsSummary NamespaceSummary) ([]QuotaHeadroom, []string) {
+	if len(c.quotas) <= 0 {
+		return nil, nil
+	}
+	requestsChange, limitsChange := recommendedUsageChange(nsSummary)
+
+	headroom := []QuotaHeadroom{}
+	warnings := []string{}
+	for _, quota := range c.quotas {
+		for _, quotaResource := range sortedKeys(quota.Spec.Hard) {
+			resourceName := corev1.ResourceName(quotaResource)
+			changed, ok := quotaResources[resourceName]
+			if !ok {
+				continue
+			}
+
+			h := QuotaHeadroom{
+				Name:     quota.Name,
+				Resource: resourceName,
+				Hard:     quota.Spec.Hard[resourceName].DeepCopy(),
+				Used:     quota.Status.Used[resourceName].DeepCopy(),
+			}
+			h.Headroom = h.Hard.DeepCopy()
+			h.Headroom.Sub(h.Used)
+			change := limitsChange[changed.name]
+			if changed.requests {
+				change = requestsChange[changed.name]
+			}
+			h.HeadroomAfter = h.Headroom.DeepCopy()
+			h.HeadroomAfter.Sub(change)
+			headroom = append(headroom, h)
+
+			if h.HeadroomAfter.Sign() < 0 && change.Sign() > 0 {
+				exceeded := h.HeadroomAfter.DeepCopy()
+				exceeded.Neg()
+				warnings = append(warnings, fmt.Sprintf("applying the recommendations would exceed %s in ResourceQuota/%s by %s", resourceName, quota.Name, exceeded.String()))
+			}
+		}
+	}
+	sort.SliceStable(headroom, func(i, j int) bool {
+		return headroom[i].Name < headroom[j].Name
+	})
+	return headroom, warnings
+}
+
+// recommendedUsageChange returns how much the cpu and memory requests and limits of the namespace change
+// when its containers are set to their guaranteed recommendations, across all replicas
+func recommendedUsageChange(nsSummary NamespaceSummary) (requests, limits corev1.ResourceList) {
+	requests = corev1.ResourceList{}
+	limits = corev1.ResourceList{}
+	for _, dSummary := range nsSummary.Deployments {
+		for _, cSummary := range dSummary.Containers {
+			addResourceList(requests, resourceChange(cSummary.Requests, cSummary.Guaranteed.Requests), int64(dSummary.Replicas))
+			addResourceList(limits, resourceChange(cSummary.Limits, cSummary.Guaranteed.Limits), int64(dSummary.Replicas))
+		}
+	}
+	return requests, limits
+}
+
+// resourceChange returns the recommended minus the current value of cpu and memory, for resources with a recommendation
+func resourceChange(current, recommended corev1.ResourceList) corev1.ResourceList {
+	change := corev1.ResourceList{}
+	for _, name := range qosResources {
+		quant, ok := recommended[name]
+		if !ok {
+			continue
+		}
+		quant = quant.DeepCopy()
+		if currentQuant, ok := current[name]; ok {
+			quant.Sub(currentQuant)
+		}
+		change[name] = quant
+	}
+	return change
+}
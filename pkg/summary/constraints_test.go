@@ -0,0 +1,153 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func TestNamespaceConstraintsClamp(t *testing.T) {
+	c := &namespaceConstraints{
+		limitRanges: []corev1.LimitRange{{
+			ObjectMeta: metav1.ObjectMeta{Name: "limits"},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{
+					{
+						Type: corev1.LimitTypePod,
+						Max:  corev1.ResourceList{"cpu": resource.MustParse("1m")},
+					},
+					{
+						Type:                 corev1.LimitTypeContainer,
+						Max:                  corev1.ResourceList{"memory": resource.MustParse("1Gi")},
+						Min:                  corev1.ResourceList{"cpu": resource.MustParse("50m")},
+						MaxLimitRequestRatio: corev1.ResourceList{"cpu": resource.MustParse("4")},
+					},
+				},
+			},
+		}},
+	}
+
+	rec := burstableRecommendation(
+		corev1.ResourceList{"cpu": resource.MustParse("10m"), "memory": resource.MustParse("512Mi")},
+		corev1.ResourceList{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("2Gi")},
+	)
+	got, warnings := c.clamp(QoSBurstable, rec)
+	gotJSON, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"50m","memory":"512Mi"},"limits":{"cpu":"500m","memory":"1Gi"}}`, string(gotJSON))
+	assert.Equal(t, []string{
+		"burstable cpu request of 10m is below the minimum of 50m in LimitRange/limits, so it was raised to the minimum",
+		"burstable cpu limit of 500m is more than 4 times the request of 50m, the maximum ratio in LimitRange/limits",
+		"burstable memory limit of 2Gi is above the maximum of 1Gi in LimitRange/limits, so it was lowered to the maximum",
+	}, warnings)
+
+	// the recommendation itself is not changed
+	assert.Equal(t, "10m", rec.Requests.Cpu().String())
+
+	got, warnings = (&namespaceConstraints{}).clamp(QoSBurstable, rec)
+	assert.Equal(t, rec, got)
+	assert.Empty(t, warnings)
+}
+
+func TestSummarizerQuotaHeadroom(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	summarizer := NewSummarizer(ForNamespace("quota"))
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "quota"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "app",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{"cpu": resource.MustParse("50m"), "memory": resource.MustParse("256Mi")},
+							Limits:   corev1.ResourceList{"memory": resource.MustParse("256Mi")},
+						},
+					}},
+				},
+			},
+		},
+	}
+	vpa := &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "quota", Labels: utils.VPALabels},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+		},
+		Status: vpav1.VerticalPodAutoscalerStatus{
+			Recommendation: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{{
+					ContainerName: "app",
+					Target:        corev1.ResourceList{"cpu": resource.MustParse("200m"), "memory": resource.MustParse("128Mi")},
+				}},
+			},
+		},
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "quota"},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				"requests.cpu":    resource.MustParse("1"),
+				"limits.memory":   resource.MustParse("1Gi"),
+				"count/configmap": resource.MustParse("10"),
+			},
+		},
+		Status: corev1.ResourceQuotaStatus{
+			Used: corev1.ResourceList{
+				"requests.cpu":  resource.MustParse("800m"),
+				"limits.memory": resource.MustParse("768Mi"),
+			},
+		},
+	}
+
+	_, err := kubeClient.Client.AppsV1().Deployments("quota").Create(context.TODO(), deployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers("quota").Create(context.TODO(), vpa, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = kubeClient.Client.CoreV1().ResourceQuotas("quota").Create(context.TODO(), quota, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	nsSummary := got.Namespaces["quota"]
+	quotaJSON, err := json.Marshal(nsSummary.Quota)
+	assert.NoError(t, err)
+	// requests go up by 150m on each of 2 replicas, and memory limits go down by 128Mi on each
+	assert.JSONEq(t, `[
+		{"name": "compute", "resource": "limits.memory", "hard": "1Gi", "used": "768Mi", "headroom": "256Mi", "headroomAfter": "512Mi"},
+		{"name": "compute", "resource": "requests.cpu", "hard": "1", "used": "800m", "headroom": "200m", "headroomAfter": "-100m"}
+	]`, string(quotaJSON))
+	assert.Equal(t, []string{"applying the recommendations would exceed requests.cpu in ResourceQuota/compute by 100m"}, nsSummary.Warnings)
+}
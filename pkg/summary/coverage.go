@@ -0,0 +1,396 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/tools/pager"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+	"github.com/fairwindsops/goldilocks/pkg/vpa"
+)
+
+// Reasons a workload is not managed by goldilocks in the coverage report
+const (
+	// ReasonNamespaceNotEnabled is a namespace without the enabled label, when the controller is not on by default
+	ReasonNamespaceNotEnabled = "NamespaceNotEnabled"
+	// ReasonNamespaceExcluded is a namespace in the excluded namespaces of the controller
+	ReasonNamespaceExcluded = "NamespaceExcluded"
+	// ReasonNamespaceOptedOut is a namespace whose enabled label is false
+	ReasonNamespaceOptedOut = "NamespaceOptedOut"
+	// ReasonForeignVPA is a workload with a VPA that was not created by goldilocks
+	ReasonForeignVPA = "ForeignVPA"
+	// ReasonUnsupportedKind is a workload of a kind that goldilocks does not create VPAs for
+	ReasonUnsupportedKind = "UnsupportedKind"
+	// ReasonVPAPending is a workload in a managed namespace that the controller has not created a VPA for yet
+	ReasonVPAPending = "VPAPending"
+)
+
+// Coverage is a report of every workload and whether goldilocks manages a VPA for it
+type Coverage struct {
+	// APIVersion is the version of the model the Coverage was created with
+	APIVersion string `json:"apiVersion"`
+
+	// Namespaces are the coverage of each namespace, by name
+	Namespaces map[string]NamespaceCoverage `json:"namespaces"`
+
+	// Total is the number of workloads across all namespaces, and Managed how many of them goldilocks manages
+	Total   int `json:"total"`
+	Managed int `json:"managed"`
+
+	// Percent is the percentage of the workloads that are managed, or 0 without any workloads
+	Percent float64 `json:"percent"`
+}
+
+// NamespaceCoverage is the coverage of the workloads in a namespace
+type NamespaceCoverage struct {
+	Namespace string `json:"namespace"`
+
+	// Enabled is whether the controller manages VPAs in the namespace, and Reason why not when it is not
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+
+	// Workloads is the coverage of each workload in the namespace, sorted by kind and name
+	Workloads []WorkloadCoverage `json:"workloads"`
+
+	// Total is the number of workloads in the namespace, and Managed how many of them goldilocks manages
+	Total   int `json:"total"`
+	Managed int `json:"managed"`
+
+	// Percent is the percentage of the workloads that are managed, or 0 without any workloads
+	Percent float64 `json:"percent"`
+}
+
+// WorkloadCoverage is whether goldilocks manages a VPA for a workload, and the reason when it does not
+type WorkloadCoverage struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Managed bool   `json:"managed"`
+	Reason  string `json:"reason,omitempty"`
+
+	// VPA is the name of the VPA of the workload, whether or not goldilocks created it
+	VPA string `json:"vpa,omitempty"`
+}
+
+// workloadRef is the kind and namespace/name of a workload, as targeted by a VPA
+type workloadRef struct {
+	kind string
+	types.NamespacedName
+}
+
+// GetCoverage returns the Coverage of every workload in the Summarizer's namespace, or all namespaces.
+// A workload is managed when it is targeted by a VPA with the Summarizer's VPA labels.
+func (s Summarizer) GetCoverage() (Coverage, error) {
+	coverage := Coverage{
+		APIVersion: APIVersion,
+		Namespaces: map[string]NamespaceCoverage{},
+	}
+	if err := s.loadFiles(); err != nil {
+		return coverage, err
+	}
+
+	namespaces, err := s.listNamespaces()
+	if err != nil {
+		return coverage, err
+	}
+	workloads, err := s.listWorkloads()
+	if err != nil {
+		return coverage, err
+	}
+	vpas, err := s.listVPAs(metav1.ListOptions{})
+	if err != nil {
+		return coverage, err
+	}
+
+	// the goldilocks and foreign VPAs of each workload
+	vpaLabels := labels.SelectorFromSet(s.vpaLabels)
+	managedVPAs := map[workloadRef]string{}
+	foreignVPAs := map[workloadRef]string{}
+	for _, v := range vpas {
+		ref := workloadRef{kind: vpaKind(v), NamespacedName: vpaTargetRef(v)}
+		if vpaLabels.Matches(labels.Set(v.Labels)) {
+			managedVPAs[ref] = v.Name
+		} else {
+			foreignVPAs[ref] = v.Name
+		}
+	}
+
+	reconciler := vpa.Reconciler{
+		OnByDefault:       s.onByDefault,
+		IncludeNamespaces: s.includeNamespaces,
+		ExcludeNamespaces: s.excludeNamespaces,
+	}
+	for _, ns := range namespaces {
+		nsCoverage := NamespaceCoverage{
+			Namespace: ns.Name,
+			Enabled:   reconciler.NamespaceIsManaged(&ns),
+			Workloads: []WorkloadCoverage{},
+		}
+		if !nsCoverage.Enabled {
+			nsCoverage.Reason = namespaceReason(ns, s.excludeNamespaces)
+		}
+		coverage.Namespaces[ns.Name] = nsCoverage
+	}
+
+	for _, ref := range workloads {
+		nsCoverage, ok := coverage.Namespaces[ref.Namespace]
+		if !ok {
+			klog.V(2).Infof("Ignoring %s/%s in missing Namespace/%s", ref.kind, ref.Name, ref.Namespace)
+			continue
+		}
+		wCoverage := WorkloadCoverage{
+			Kind: ref.kind,
+			Name: ref.Name,
+		}
+		if name, ok := managedVPAs[ref]; ok {
+			wCoverage.Managed = true
+			wCoverage.VPA = name
+		} else {
+			wCoverage.VPA = foreignVPAs[ref]
+			switch {
+			case ref.kind != "Deployment":
+				wCoverage.Reason = ReasonUnsupportedKind
+			case !nsCoverage.Enabled:
+				wCoverage.Reason = nsCoverage.Reason
+			case wCoverage.VPA != "":
+				wCoverage.Reason = ReasonForeignVPA
+			default:
+				wCoverage.Reason = ReasonVPAPending
+			}
+		}
+		nsCoverage.Workloads = append(nsCoverage.Workloads, wCoverage)
+		nsCoverage.Total++
+		coverage.Total++
+		if wCoverage.Managed {
+			nsCoverage.Managed++
+			coverage.Managed++
+		}
+		coverage.Namespaces[ref.Namespace] = nsCoverage
+	}
+
+	for name, nsCoverage := range coverage.Namespaces {
+		sort.SliceStable(nsCoverage.Workloads, func(i, j int) bool {
+			a, b := nsCoverage.Workloads[i], nsCoverage.Workloads[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			return a.Name < b.Name
+		})
+		nsCoverage.Percent = percent(nsCoverage.Managed, nsCoverage.Total)
+		coverage.Namespaces[name] = nsCoverage
+	}
+	coverage.Percent = percent(coverage.Managed, coverage.Total)
+	return coverage, nil
+}
+
+// namespaceReason returns why the controller does not manage the namespace, which has an enabled label of false,
+// is one of the excluded namespaces, or is otherwise not enabled
+func namespaceReason(namespace corev1.Namespace, excludeNamespaces []string) string {
+	for k, v := range namespace.Labels {
+		if strings.ToLower(k) != utils.VpaEnabledLabel {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(v); err == nil && !enabled {
+			return ReasonNamespaceOptedOut
+		}
+		return ReasonNamespaceNotEnabled
+	}
+	for _, excluded := range excludeNamespaces {
+		if namespace.Name == excluded {
+			return ReasonNamespaceExcluded
+		}
+	}
+	return ReasonNamespaceNotEnabled
+}
+
+// percent returns the managed workloads as a percentage of all workloads, or 0 without any workloads
+func percent(managed, workloads int) float64 {
+	if workloads <= 0 {
+		return 0
+	}
+	return float64(managed) * 100 / float64(workloads)
+}
+
+// listNamespaces lists the Summarizer's namespace, or all namespaces
+func (s Summarizer) listNamespaces() ([]corev1.Namespace, error) {
+	namespaces := []corev1.Namespace{}
+	if s.namespace != namespaceAllNamespaces {
+		ns, err := s.kubeClient.Client.CoreV1().Namespaces().Get(context.TODO(), s.namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return append(namespaces, *ns), nil
+	}
+	if s.cache != nil {
+		cached, err := s.cache.namespaces.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range cached {
+			namespaces = append(namespaces, *ns)
+		}
+		return namespaces, nil
+	}
+	list, err := s.kubeClient.Client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// listWorkloads lists the Deployments, StatefulSets, DaemonSets and CronJobs in the Summarizer's namespace, or all namespaces.
+// Deployments are listed from the cache if there is one, and the rest from the API server a page at a time.
+func (s Summarizer) listWorkloads() ([]workloadRef, error) {
+	refs := []workloadRef{}
+	deployments, err := s.listDeployments(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		refs = append(refs, workloadRef{kind: "Deployment", NamespacedName: types.NamespacedName{Namespace: d.Namespace, Name: d.Name}})
+	}
+
+	client := s.kubeClient.Client
+	for _, workloads := range []struct {
+		kind string
+		list func(opts metav1.ListOptions) (runtime.Object, error)
+	}{
+		{"StatefulSet", func(opts metav1.ListOptions) (runtime.Object, error) {
+			return client.AppsV1().StatefulSets(s.namespace).List(context.TODO(), opts)
+		}},
+		{"DaemonSet", func(opts metav1.ListOptions) (runtime.Object, error) {
+			return client.AppsV1().DaemonSets(s.namespace).List(context.TODO(), opts)
+		}},
+		{"CronJob", func(opts metav1.ListOptions) (runtime.Object, error) {
+			return client.BatchV1beta1().CronJobs(s.namespace).List(context.TODO(), opts)
+		}},
+	} {
+		kind := workloads.kind
+		listPager := pager.New(pager.SimplePageFunc(workloads.list))
+		listPager.PageSize = s.pageSize
+		err := listPager.EachListItem(context.TODO(), metav1.ListOptions{}, func(obj runtime.Object) error {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, workloadRef{kind: kind, NamespacedName: types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+// CoverageOutputFormats are the supported output formats of a Coverage
+var CoverageOutputFormats = []string{
+	OutputJSON,
+	OutputYAML,
+	OutputTable,
+}
+
+// WriteCoverage writes the Coverage to the writer in the json, yaml or table output format
+func WriteCoverage(w io.Writer, data Coverage, format string) error {
+	switch format {
+	case OutputJSON:
+		coverageJSON, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(coverageJSON))
+		return err
+	case OutputYAML:
+		coverageYAML, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(coverageYAML)
+		return err
+	case OutputTable:
+		return writeCoverageTable(w, data)
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", format, strings.Join(CoverageOutputFormats, ", "))
+	}
+}
+
+// writeCoverageTable writes a row for each workload, followed by the coverage of each namespace and the whole cluster
+func writeCoverageTable(w io.Writer, data Coverage) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tabWriter, "NAMESPACE\tKIND\tWORKLOAD\tMANAGED\tREASON\tVPA"); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(data.Namespaces))
+	for name := range data.Namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, wCoverage := range data.Namespaces[name].Workloads {
+			row := []string{name, wCoverage.Kind, wCoverage.Name, strconv.FormatBool(wCoverage.Managed), wCoverage.Reason, wCoverage.VPA}
+			for i := range row {
+				if row[i] == "" {
+					row[i] = "-"
+				}
+			}
+			if _, err := fmt.Fprintln(tabWriter, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprintln(tabWriter); err != nil {
+		return err
+	}
+	for _, name := range names {
+		nsCoverage := data.Namespaces[name]
+		if _, err := fmt.Fprintf(tabWriter, "Namespace %s:\t%s\n", name, coverageString(nsCoverage.Managed, nsCoverage.Total, nsCoverage.Percent)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(tabWriter, "Total:\t%s\n", coverageString(data.Managed, data.Total, data.Percent)); err != nil {
+		return err
+	}
+	return tabWriter.Flush()
+}
+
+// coverageString describes how many workloads are managed, e.g. "3 of 4 workloads managed (75%)"
+func coverageString(managed, workloads int, percent float64) string {
+	return fmt.Sprintf("%d of %d workloads managed (%.0f%%)", managed, workloads, percent)
+}
+
+// vpaKind is the kind of the workload targeted by the VPA, which is a Deployment without a targetRef
+func vpaKind(v vpav1.VerticalPodAutoscaler) string {
+	if v.Spec.TargetRef != nil && v.Spec.TargetRef.Kind != "" {
+		return v.Spec.TargetRef.Kind
+	}
+	return "Deployment"
+}
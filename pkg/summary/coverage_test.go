@@ -0,0 +1,154 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func TestGetCoverage(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "enabled", Labels: map[string]string{utils.VpaEnabledLabel: "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Labels: map[string]string{utils.VpaEnabledLabel: "false"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "excluded"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty"}},
+	}
+	for _, ns := range namespaces {
+		_, err := kubeClient.Client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	createTestWorkload(t, kubeClient, kubeClientVPA, "enabled", "managed")
+	for _, d := range []*appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "enabled"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: "enabled"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "opted-out"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "excluded"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "plain"}},
+	} {
+		_, err := kubeClient.Client.AppsV1().Deployments(d.Namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "enabled"}}
+	_, err := kubeClient.Client.AppsV1().StatefulSets("enabled").Create(context.TODO(), statefulSet, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	foreignVPA := &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "foreign-vpa", Namespace: "enabled"},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "foreign"},
+		},
+	}
+	_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers("enabled").Create(context.TODO(), foreignVPA, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	summarizer := NewSummarizer(ForManagedNamespaces(false, nil, []string{"excluded"}))
+	got, err := summarizer.GetCoverage()
+	assert.NoError(t, err)
+
+	assert.Equal(t, APIVersion, got.APIVersion)
+	assert.Equal(t, []WorkloadCoverage{
+		{Kind: "Deployment", Name: "foreign", Reason: ReasonForeignVPA, VPA: "foreign-vpa"},
+		{Kind: "Deployment", Name: "managed", Managed: true, VPA: "managed"},
+		{Kind: "Deployment", Name: "pending", Reason: ReasonVPAPending},
+		{Kind: "StatefulSet", Name: "db", Reason: ReasonUnsupportedKind},
+	}, got.Namespaces["enabled"].Workloads)
+	assert.True(t, got.Namespaces["enabled"].Enabled)
+	assert.Equal(t, 25.0, got.Namespaces["enabled"].Percent)
+
+	tests := []struct {
+		namespace string
+		reason    string
+	}{
+		{"opted-out", ReasonNamespaceOptedOut},
+		{"excluded", ReasonNamespaceExcluded},
+		{"plain", ReasonNamespaceNotEnabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			nsCoverage := got.Namespaces[tt.namespace]
+			assert.False(t, nsCoverage.Enabled)
+			assert.Equal(t, tt.reason, nsCoverage.Reason)
+			assert.Equal(t, []WorkloadCoverage{{Kind: "Deployment", Name: "app", Reason: tt.reason}}, nsCoverage.Workloads)
+		})
+	}
+
+	assert.Equal(t, NamespaceCoverage{Namespace: "empty", Reason: ReasonNamespaceNotEnabled, Workloads: []WorkloadCoverage{}}, got.Namespaces["empty"])
+	assert.Equal(t, 7, got.Total)
+	assert.Equal(t, 1, got.Managed)
+
+	// on by default, every namespace without a label that is not excluded is enabled
+	summarizer = NewSummarizer(ForManagedNamespaces(true, nil, []string{"excluded"}))
+	got, err = summarizer.GetCoverage()
+	assert.NoError(t, err)
+	assert.True(t, got.Namespaces["plain"].Enabled)
+	assert.Equal(t, ReasonVPAPending, got.Namespaces["plain"].Workloads[0].Reason)
+	assert.Equal(t, ReasonNamespaceExcluded, got.Namespaces["excluded"].Reason)
+	assert.Equal(t, ReasonNamespaceOptedOut, got.Namespaces["opted-out"].Reason)
+
+	// workloads of every kind are listed the same a page at a time
+	summarizer = NewSummarizer(ForManagedNamespaces(true, nil, []string{"excluded"}), WithPageSize(1))
+	paged, err := summarizer.GetCoverage()
+	assert.NoError(t, err)
+	assert.Equal(t, got, paged)
+}
+
+func TestWriteCoverageTable(t *testing.T) {
+	data := Coverage{
+		APIVersion: APIVersion,
+		Namespaces: map[string]NamespaceCoverage{
+			"demo": {
+				Namespace: "demo",
+				Enabled:   true,
+				Workloads: []WorkloadCoverage{
+					{Kind: "DaemonSet", Name: "agent", Reason: ReasonUnsupportedKind},
+					{Kind: "Deployment", Name: "web", Managed: true, VPA: "web"},
+				},
+				Total:   2,
+				Managed: 1,
+				Percent: 50,
+			},
+		},
+		Total:   2,
+		Managed: 1,
+		Percent: 50,
+	}
+	out := &bytes.Buffer{}
+	assert.NoError(t, WriteCoverage(out, data, OutputTable))
+	assert.Equal(t, `NAMESPACE   KIND         WORKLOAD   MANAGED   REASON            VPA
+demo        DaemonSet    agent      false     UnsupportedKind   -
+demo        Deployment   web        true      -                 web
+
+Namespace demo:   1 of 2 workloads managed (50%)
+Total:            1 of 2 workloads managed (50%)
+`, out.String())
+
+	assert.EqualError(t, WriteCoverage(out, data, OutputCSV), `unsupported output format "csv", must be one of: json, yaml, table`)
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Footprint is the requested and recommended resources of a workload
+type Footprint struct {
+	PodRequests   corev1.ResourceList `json:"podRequests"`
+	PodTarget     corev1.ResourceList `json:"podTarget"`
+	TotalRequests corev1.ResourceList `json:"totalRequests"`
+	TotalTarget   corev1.ResourceList `json:"totalTarget"`
+}
+
+// deploymentFootprint sums the requests and targets of the summarized containers of a Deployment,
+// for a single pod and multiplied by the desired replicas. Like the delta, it leaves out the resources of each
+// container without both a request and a target, so that missing requests do not look like negative savings.
+func deploymentFootprint(dSummary DeploymentSummary) Footprint {
+	f := Footprint{
+		PodRequests:   corev1.ResourceList{},
+		PodTarget:     corev1.ResourceList{},
+		TotalRequests: corev1.ResourceList{},
+		TotalTarget:   corev1.ResourceList{},
+	}
+	for _, cSummary := range dSummary.Containers {
+		requests, target := comparableResources(cSummary.Requests, cSummary.Target)
+		addResourceList(f.PodRequests, requests, 1)
+		addResourceList(f.PodTarget, target, 1)
+	}
+	addResourceList(f.TotalRequests, f.PodRequests, int64(dSummary.Replicas))
+	addResourceList(f.TotalTarget, f.PodTarget, int64(dSummary.Replicas))
+	return f
+}
+
+// resourceDelta returns the requests minus the target for each recommended resource.
+// Resources without a request or a target are left out, as there is nothing to compare.
+func resourceDelta(requests, target corev1.ResourceList) corev1.ResourceList {
+	delta := corev1.ResourceList{}
+	requests, target = comparableResources(requests, target)
+	for name, request := range requests {
+		quant := request.DeepCopy()
+		quant.Sub(target[name])
+		delta[name] = quant
+	}
+	return delta
+}
+
+// comparableResources returns the requests and targets of the recommended resources that have both a non-zero
+// request and a target
+func comparableResources(requests, target corev1.ResourceList) (corev1.ResourceList, corev1.ResourceList) {
+	comparableRequests, comparableTarget := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, name := range qosResources {
+		request, ok := requests[name]
+		if !ok || request.IsZero() {
+			continue
+		}
+		recommended, ok := target[name]
+		if !ok {
+			continue
+		}
+		comparableRequests[name] = request.DeepCopy()
+		comparableTarget[name] = recommended.DeepCopy()
+	}
+	return comparableRequests, comparableTarget
+}
+
+// addResourceList adds each quantity in the list, multiplied by the count, to the total
+func addResourceList(total, rl corev1.ResourceList, count int64) {
+	for name, quant := range rl {
+		scaled := *resource.NewMilliQuantity(quant.MilliValue()*count, quant.Format)
+		if existing, ok := total[name]; ok {
+			scaled.Add(existing)
+		}
+		total[name] = scaled
+	}
+}
+
+// deploymentReplicas returns the desired replicas of the Deployment, which defaults to one when unset
+func deploymentReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// DeltaString describes a cpu and memory delta in cores and GiB, e.g. "1.5 cores / 2 GiB over-provisioned"
+func DeltaString(delta corev1.ResourceList) string {
+	cores, gibibytes := coresAndGiB(delta)
+
+	provisioning := "over-provisioned"
+	if cores <= 0 && gibibytes <= 0 && (cores < 0 || gibibytes < 0) {
+		provisioning = "under-provisioned"
+		cores, gibibytes = -cores, -gibibytes
+	}
+	return fmt.Sprintf("%s cores / %s GiB %s", formatAmount(cores), formatAmount(gibibytes), provisioning)
+}
+
+// SizeString describes the cpu and memory in a list in cores and GiB, e.g. "1.5 cores / 2 GiB"
+func SizeString(rl corev1.ResourceList) string {
+	cores, gibibytes := coresAndGiB(rl)
+	return fmt.Sprintf("%s cores / %s GiB", formatAmount(cores), formatAmount(gibibytes))
+}
+
+func coresAndGiB(rl corev1.ResourceList) (float64, float64) {
+	cpu := rl[corev1.ResourceCPU]
+	memory := rl[corev1.ResourceMemory]
+	return float64(cpu.MilliValue()) / 1000, float64(memory.Value()) / (1 << 30)
+}
+
+// formatAmount formats the amount with at most two decimal places
+func formatAmount(amount float64) string {
+	rounded := math.Round(amount*100) / 100
+	if rounded == 0 {
+		// avoid printing negative zero
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// EfficiencyPolicy is the configuration for scoring how well the requests of workloads fit their VPA bounds.
+// A resource whose request is within the lower and upper bound scores 100. Above the upper bound it scores the
+// upper bound as a percentage of the request, and below the lower bound the request as a percentage of the lower bound.
+type EfficiencyPolicy struct {
+	// Weights are the relative weights of cpu and memory in the score of a container
+	Weights map[corev1.ResourceName]float64 `json:"weights"`
+
+	// Grades are the letter grades and the minimum score for each. A score below all of them gets the lowest grade.
+	Grades []Grade `json:"grades"`
+}
+
+// Grade is a letter grade given to efficiency scores of at least MinScore
+type Grade struct {
+	Grade    string  `json:"grade"`
+	MinScore float64 `json:"minScore"`
+}
+
+// Efficiency is the efficiency score of a workload, namespace or cluster from 0 to 100, and its letter grade
+type Efficiency struct {
+	Score float64 `json:"score"`
+	Grade string  `json:"grade"`
+}
+
+// DefaultEfficiencyPolicy returns the policy used when none is configured, which weighs cpu and memory equally
+// and grades scores of 90 and above as A, 80 as B, 70 as C, 60 as D, and anything lower as F
+func DefaultEfficiencyPolicy() *EfficiencyPolicy {
+	return &EfficiencyPolicy{
+		Weights: map[corev1.ResourceName]float64{
+			corev1.ResourceCPU:    1,
+			corev1.ResourceMemory: 1,
+		},
+		Grades: []Grade{
+			{"A", 90},
+			{"B", 80},
+			{"C", 70},
+			{"D", 60},
+			{"F", 0},
+		},
+	}
+}
+
+// LoadEfficiencyPolicy reads an EfficiencyPolicy from a YAML or JSON file.
+// The default weights or grades are used when the file does not set them.
+func LoadEfficiencyPolicy(path string) (*EfficiencyPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseEfficiencyPolicy(data)
+}
+
+func parseEfficiencyPolicy(data []byte) (*EfficiencyPolicy, error) {
+	policy := &EfficiencyPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, err
+	}
+	defaults := DefaultEfficiencyPolicy()
+	if len(policy.Weights) <= 0 {
+		policy.Weights = defaults.Weights
+	}
+	if len(policy.Grades) <= 0 {
+		policy.Grades = defaults.Grades
+	}
+
+	total := 0.0
+	for name, weight := range policy.Weights {
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			return nil, fmt.Errorf("invalid weight for %s, only cpu and memory have VPA bounds", name)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("invalid %s weight %v, must not be negative", name, weight)
+		}
+		total += weight
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("invalid weights, at least one must be positive")
+	}
+	for _, grade := range policy.Grades {
+		if grade.Grade == "" {
+			return nil, fmt.Errorf("invalid grade with a minScore of %v, must have a name", grade.MinScore)
+		}
+	}
+	return policy, nil
+}
+
+// grade returns the highest grade whose minimum the score reaches, or the lowest grade
+func (p EfficiencyPolicy) grade(score float64) string {
+	grades := append([]Grade{}, p.Grades...)
+	sort.SliceStable(grades, func(i, j int) bool {
+		return grades[i].MinScore > grades[j].MinScore
+	})
+	for _, grade := range grades {
+		if score >= grade.MinScore {
+			return grade.Grade
+		}
+	}
+	return grades[len(grades)-1].Grade
+}
+
+// efficiency returns the Efficiency of the score, rounded to one decimal place
+func (p EfficiencyPolicy) efficiency(score float64) *Efficiency {
+	score = math.Round(score*10) / 10
+	return &Efficiency{
+		Score: score,
+		Grade: p.grade(score),
+	}
+}
+
+// deploymentScore returns the average score of the containers of the deployment that have VPA bounds
+func (p EfficiencyPolicy) deploymentScore(dSummary DeploymentSummary) (float64, bool) {
+	total, count := 0.0, 0
+	for _, cSummary := range dSummary.Containers {
+		if score, ok := p.containerScore(cSummary); ok {
+			total += score
+			count++
+		}
+	}
+	if count <= 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// containerScore returns the weighted average of the scores of the resources of the container that have VPA bounds
+func (p EfficiencyPolicy) containerScore(cSummary ContainerSummary) (float64, bool) {
+	total, weights := 0.0, 0.0
+	for _, name := range qosResources {
+		weight := p.Weights[name]
+		if weight <= 0 {
+			continue
+		}
+		score, ok := resourceScore(cSummary.Requests, cSummary.LowerBound, cSummary.UpperBound, name)
+		if !ok {
+			continue
+		}
+		total += weight * score
+		weights += weight
+	}
+	if weights <= 0 {
+		return 0, false
+	}
+	return total / weights, true
+}
+
+// resourceScore scores how far the named request falls outside its VPA bounds, from 0 to 100.
+// A missing request scores 0, and a resource without both bounds is not scored.
+func resourceScore(requests, lowerBound, upperBound corev1.ResourceList, name corev1.ResourceName) (float64, bool) {
+	lower, hasLower := lowerBound[name]
+	upper, hasUpper := upperBound[name]
+	if !hasLower || !hasUpper {
+		return 0, false
+	}
+	request := requests[name]
+	r, l, u := float64(request.MilliValue()), float64(lower.MilliValue()), float64(upper.MilliValue())
+	switch {
+	case r > u:
+		return 100 * u / r, true
+	case r < l:
+		return 100 * r / l, true
+	default:
+		return 100, true
+	}
+}
+
+// efficiencyTotal is the sum of deployment scores weighted by their replicas, for rolling them up to a namespace or cluster
+type efficiencyTotal struct {
+	score    float64
+	replicas float64
+}
+
+// add adds the deployment score to the total, weighted by its replicas
+func (t *efficiencyTotal) add(score float64, replicas int32) {
+	t.score += score * float64(replicas)
+	t.replicas += float64(replicas)
+}
+
+// efficiency returns the Efficiency of the total, or nil without any replicas
+func (t efficiencyTotal) efficiency(p EfficiencyPolicy) *Efficiency {
+	if t.replicas <= 0 {
+		return nil
+	}
+	return p.efficiency(t.score / t.replicas)
+}
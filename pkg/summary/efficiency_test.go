@@ -0,0 +1,187 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func TestResourceScore(t *testing.T) {
+	lowerBound := corev1.ResourceList{"cpu": resource.MustParse("100m")}
+	upperBound := corev1.ResourceList{"cpu": resource.MustParse("500m")}
+	tests := []struct {
+		name     string
+		requests corev1.ResourceList
+		want     float64
+	}{
+		{"within bounds", corev1.ResourceList{"cpu": resource.MustParse("200m")}, 100},
+		{"at the upper bound", corev1.ResourceList{"cpu": resource.MustParse("500m")}, 100},
+		{"over-provisioned", corev1.ResourceList{"cpu": resource.MustParse("2")}, 25},
+		{"under-provisioned", corev1.ResourceList{"cpu": resource.MustParse("50m")}, 50},
+		{"no request", corev1.ResourceList{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resourceScore(tt.requests, lowerBound, upperBound, corev1.ResourceCPU)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := resourceScore(corev1.ResourceList{"memory": resource.MustParse("1Gi")}, lowerBound, upperBound, corev1.ResourceMemory)
+	assert.False(t, ok, "resources without bounds are not scored")
+}
+
+func TestContainerScore(t *testing.T) {
+	cSummary := ContainerSummary{
+		Requests:   corev1.ResourceList{"cpu": resource.MustParse("2"), "memory": resource.MustParse("128Mi")},
+		LowerBound: corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("64Mi")},
+		UpperBound: corev1.ResourceList{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("256Mi")},
+	}
+
+	got, ok := DefaultEfficiencyPolicy().containerScore(cSummary)
+	assert.True(t, ok)
+	assert.Equal(t, 62.5, got)
+
+	cpuOnly := EfficiencyPolicy{Weights: map[corev1.ResourceName]float64{"cpu": 3, "memory": 1}}
+	got, ok = cpuOnly.containerScore(cSummary)
+	assert.True(t, ok)
+	assert.Equal(t, 43.75, got)
+
+	_, ok = DefaultEfficiencyPolicy().containerScore(ContainerSummary{})
+	assert.False(t, ok)
+}
+
+func TestEfficiencyGrade(t *testing.T) {
+	policy := DefaultEfficiencyPolicy()
+	assert.Equal(t, &Efficiency{Score: 90, Grade: "A"}, policy.efficiency(90))
+	assert.Equal(t, &Efficiency{Score: 89.9, Grade: "B"}, policy.efficiency(89.94))
+	assert.Equal(t, &Efficiency{Score: 12.3, Grade: "F"}, policy.efficiency(12.34))
+
+	// grades are matched from the highest minimum, whatever their order, and lower scores get the lowest grade
+	policy.Grades = []Grade{{"ok", 50}, {"good", 80}}
+	assert.Equal(t, "good", policy.grade(95))
+	assert.Equal(t, "ok", policy.grade(60))
+	assert.Equal(t, "ok", policy.grade(10))
+}
+
+func TestParseEfficiencyPolicy(t *testing.T) {
+	policy, err := parseEfficiencyPolicy([]byte(`
+weights:
+  cpu: 2
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[corev1.ResourceName]float64{"cpu": 2}, policy.Weights)
+	assert.Equal(t, DefaultEfficiencyPolicy().Grades, policy.Grades)
+
+	policy, err = parseEfficiencyPolicy([]byte(`
+grades:
+- grade: pass
+  minScore: 75
+- grade: fail
+  minScore: 0
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultEfficiencyPolicy().Weights, policy.Weights)
+	assert.Equal(t, []Grade{{"pass", 75}, {"fail", 0}}, policy.Grades)
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unknown resource", "weights: {ephemeral-storage: 1}", "invalid weight for ephemeral-storage, only cpu and memory have VPA bounds"},
+		{"negative weight", "weights: {cpu: -1}", "invalid cpu weight -1, must not be negative"},
+		{"zero weights", "weights: {cpu: 0, memory: 0}", "invalid weights, at least one must be positive"},
+		{"unnamed grade", "grades: [{minScore: 50}]", "invalid grade with a minScore of 50, must have a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseEfficiencyPolicy([]byte(tt.data))
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestSummarizerEfficiency(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+	summarizer := NewSummarizer()
+
+	// web requests twice its upper bound of cpu and scores 75, worker is within its bounds and scores 100
+	for _, w := range []struct {
+		name     string
+		replicas int32
+		cpu      string
+	}{
+		{"web", 3, "1"},
+		{"worker", 1, "250m"},
+	} {
+		replicas := w.replicas
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: w.name, Namespace: "testing"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"cpu": resource.MustParse(w.cpu), "memory": resource.MustParse("128Mi")},
+					},
+				}}}},
+			},
+		}
+		_, err := kubeClient.Client.AppsV1().Deployments(d.Namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		vpa := &vpav1.VerticalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: w.name, Namespace: "testing", Labels: utils.VPALabels},
+			Spec: vpav1.VerticalPodAutoscalerSpec{
+				TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: w.name},
+			},
+			Status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: &vpav1.RecommendedPodResources{
+					ContainerRecommendations: []vpav1.RecommendedContainerResources{{
+						ContainerName: "app",
+						Target:        corev1.ResourceList{"cpu": resource.MustParse("250m"), "memory": resource.MustParse("128Mi")},
+						LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("64Mi")},
+						UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("256Mi")},
+					}},
+				},
+			},
+		}
+		_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers(vpa.Namespace).Create(context.TODO(), vpa, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+	assert.Equal(t, &Efficiency{Score: 75, Grade: "C"}, got.Namespaces["testing"].Deployments["web"].Efficiency)
+	assert.Equal(t, &Efficiency{Score: 100, Grade: "A"}, got.Namespaces["testing"].Deployments["worker"].Efficiency)
+	// weighted by replicas, (3*75 + 100) / 4
+	assert.Equal(t, &Efficiency{Score: 81.3, Grade: "B"}, got.Namespaces["testing"].Efficiency)
+	assert.Equal(t, got.Namespaces["testing"].Efficiency, got.Efficiency)
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/klog"
+)
+
+// Placeholders of a helm values path template
+const (
+	HelmContainerPlaceholder  = "{{container}}"
+	HelmDeploymentPlaceholder = "{{deployment}}"
+	HelmReleasePlaceholder    = "{{release}}"
+)
+
+// DefaultHelmValuesPath is where container resources are set in the values of most charts with a value per container
+const DefaultHelmValuesPath = HelmContainerPlaceholder + ".resources"
+
+// Labels and annotations that Helm, and the charts it deploys, set on a workload with the name of its release
+const (
+	helmReleaseNameAnnotation = "meta.helm.sh/release-name"
+	helmManagedByLabel        = "app.kubernetes.io/managed-by"
+	helmInstanceLabel         = "app.kubernetes.io/instance"
+	helmReleaseLabel          = "release"
+)
+
+// helmRelease returns the name of the Helm release that deployed the Deployment, or an empty string if it was not deployed by Helm
+func helmRelease(deployment *appsv1.Deployment) string {
+	if release := deployment.Annotations[helmReleaseNameAnnotation]; release != "" {
+		return release
+	}
+	if deployment.Labels[helmManagedByLabel] == "Helm" && deployment.Labels[helmInstanceLabel] != "" {
+		return deployment.Labels[helmInstanceLabel]
+	}
+	return deployment.Labels[helmReleaseLabel]
+}
+
+// helmValues is the values.yaml fragment of a single Helm release
+type helmValues struct {
+	namespace string
+	release   string
+	values    map[string]interface{}
+}
+
+// helmReleaseValues returns a values.yaml fragment for each Helm release in the Summary that sets the resources of its containers
+// to the chosen recommendation, at the path of the values path template. The template is a dot separated path
+// which may contain the {{container}}, {{deployment}} and {{release}} placeholders.
+// Workloads that were not deployed by Helm and containers without the chosen recommendation are left out,
+// and so are releases where the path does not tell two containers apart, with a warning.
+func helmReleaseValues(data Summary, valuesPath, qos string) ([]helmValues, error) {
+	if err := validateQoS(qos); err != nil {
+		return nil, err
+	}
+
+	releases := map[string]*helmValues{}
+	collisions := map[string]bool{}
+	for _, nsName := range sortedKeys(data.Namespaces) {
+		nsSummary := data.Namespaces[nsName]
+		for _, dName := range sortedKeys(nsSummary.Deployments) {
+			dSummary := nsSummary.Deployments[dName]
+			if dSummary.HelmRelease == "" {
+				continue
+			}
+
+			key := nsSummary.Namespace + "/" + dSummary.HelmRelease
+			if collisions[key] {
+				continue
+			}
+			release, ok := releases[key]
+			if !ok {
+				release = &helmValues{
+					namespace: nsSummary.Namespace,
+					release:   dSummary.HelmRelease,
+					values:    map[string]interface{}{},
+				}
+			}
+
+			for _, cName := range sortedKeys(dSummary.Containers) {
+				resources := qosResourceRequirements(dSummary.Containers[cName], qos)
+				if resources == nil {
+					continue
+				}
+				path, err := helmValuesPath(valuesPath, cName, dSummary.DeploymentName, dSummary.HelmRelease)
+				if err != nil {
+					return nil, err
+				}
+				if err := setHelmValue(release.values, path, resources); err != nil {
+					klog.Warningf("Skipping helm values of release %s: %v, use a helm values path with %s", key, err, HelmDeploymentPlaceholder)
+					collisions[key] = true
+					break
+				}
+			}
+
+			if collisions[key] {
+				delete(releases, key)
+			} else if len(release.values) > 0 {
+				releases[key] = release
+			}
+		}
+	}
+
+	values := []helmValues{}
+	for _, release := range releases {
+		values = append(values, *release)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].namespace != values[j].namespace {
+			return values[i].namespace < values[j].namespace
+		}
+		return values[i].release < values[j].release
+	})
+	return values, nil
+}
+
+// helmValuesPath fills in the placeholders of the values path template and splits it into its keys
+func helmValuesPath(template, container, deployment, release string) ([]string, error) {
+	path := strings.NewReplacer(
+		HelmContainerPlaceholder, container,
+		HelmDeploymentPlaceholder, deployment,
+		HelmReleasePlaceholder, release,
+	).Replace(template)
+	if strings.Contains(path, "{{") {
+		return nil, fmt.Errorf("unsupported placeholder in helm values path %q, must be one of: %s, %s, %s", template, HelmContainerPlaceholder, HelmDeploymentPlaceholder, HelmReleasePlaceholder)
+	}
+
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid helm values path %q: empty key", template)
+		}
+	}
+	return keys, nil
+}
+
+// setHelmValue sets the value at the path of keys in the values, creating maps along the way.
+// It is an error for the path to already be set, which happens when the path template does not tell two containers apart.
+func setHelmValue(values map[string]interface{}, path []string, value interface{}) error {
+	for i, key := range path[:len(path)-1] {
+		next, ok := values[key]
+		if !ok {
+			next = map[string]interface{}{}
+			values[key] = next
+		}
+		nextValues, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("helm values path %s is set by more than one container", strings.Join(path[:i+1], "."))
+		}
+		values = nextValues
+	}
+
+	last := path[len(path)-1]
+	if _, ok := values[last]; ok {
+		return fmt.Errorf("helm values path %s is set by more than one container", strings.Join(path, "."))
+	}
+	values[last] = value
+	return nil
+}
+
+// WriteHelmValues writes the values.yaml fragment of each Helm release in the Summary as a YAML document,
+// with a comment naming the release
+func WriteHelmValues(w io.Writer, data Summary, valuesPath, qos string) error {
+	releases, err := helmReleaseValues(data, valuesPath, qos)
+	if err != nil {
+		return err
+	}
+
+	for i, release := range releases {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# Release %s in Namespace %s\n", release.release, release.namespace); err != nil {
+			return err
+		}
+		if err := writeYAML(w, release.values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
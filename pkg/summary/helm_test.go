@@ -0,0 +1,145 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testHelmSummary = Summary{
+	Namespaces: map[string]NamespaceSummary{
+		"testing": {
+			Namespace: "testing",
+			Deployments: map[string]DeploymentSummary{
+				"web": {
+					DeploymentName: "web",
+					HelmRelease:    "shop",
+					Containers: map[string]ContainerSummary{
+						"app": {
+							ContainerName: "app",
+							Guaranteed:    guaranteedRecommendation(corev1.ResourceList{"cpu": resource.MustParse("25m"), "memory": resource.MustParse("128Mi")}),
+						},
+					},
+				},
+				"worker": {
+					DeploymentName: "worker",
+					HelmRelease:    "shop",
+					Containers: map[string]ContainerSummary{
+						"app": {
+							ContainerName: "app",
+							Guaranteed:    guaranteedRecommendation(corev1.ResourceList{"cpu": resource.MustParse("50m")}),
+						},
+					},
+				},
+				"unmanaged": {
+					DeploymentName: "unmanaged",
+					Containers: map[string]ContainerSummary{
+						"app": {
+							ContainerName: "app",
+							Guaranteed:    guaranteedRecommendation(corev1.ResourceList{"cpu": resource.MustParse("10m")}),
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestHelmRelease(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{"helm 3", map[string]string{"app.kubernetes.io/instance": "other"}, map[string]string{"meta.helm.sh/release-name": "shop"}, "shop"},
+		{"managed by helm", map[string]string{"app.kubernetes.io/managed-by": "Helm", "app.kubernetes.io/instance": "shop"}, nil, "shop"},
+		{"instance only", map[string]string{"app.kubernetes.io/instance": "shop"}, nil, ""},
+		{"release label", map[string]string{"release": "shop"}, nil, "shop"},
+		{"not helm", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels, Annotations: tt.annotations}}
+			assert.Equal(t, tt.want, helmRelease(deployment))
+		})
+	}
+}
+
+func TestWriteHelmValues(t *testing.T) {
+	got := &bytes.Buffer{}
+	assert.NoError(t, WriteHelmValues(got, testHelmSummary, "{{deployment}}.{{container}}.resources", QoSGuaranteed))
+	assert.Equal(t, `# Release shop in Namespace testing
+web:
+  app:
+    resources:
+      limits:
+        cpu: 25m
+        memory: 128Mi
+      requests:
+        cpu: 25m
+        memory: 128Mi
+worker:
+  app:
+    resources:
+      limits:
+        cpu: 50m
+      requests:
+        cpu: 50m
+`, got.String())
+
+	// the default path does not tell the containers of both deployments apart, so that release is skipped and the others are written
+	data := Summary{Namespaces: map[string]NamespaceSummary{"testing": {Namespace: "testing", Deployments: map[string]DeploymentSummary{}}}}
+	for name, dSummary := range testHelmSummary.Namespaces["testing"].Deployments {
+		data.Namespaces["testing"].Deployments[name] = dSummary
+	}
+	data.Namespaces["testing"].Deployments["blog"] = DeploymentSummary{
+		DeploymentName: "blog",
+		HelmRelease:    "blog",
+		Containers: map[string]ContainerSummary{
+			"app": {
+				ContainerName: "app",
+				Guaranteed:    guaranteedRecommendation(corev1.ResourceList{"cpu": resource.MustParse("10m")}),
+			},
+		},
+	}
+	got = &bytes.Buffer{}
+	assert.NoError(t, WriteHelmValues(got, data, DefaultHelmValuesPath, QoSGuaranteed))
+	assert.Equal(t, `# Release blog in Namespace testing
+app:
+  resources:
+    limits:
+      cpu: 10m
+    requests:
+      cpu: 10m
+`, got.String())
+
+	err := WriteHelmValues(&bytes.Buffer{}, testHelmSummary, "{{image}}.resources", QoSGuaranteed)
+	assert.EqualError(t, err, `unsupported placeholder in helm values path "{{image}}.resources", must be one of: {{container}}, {{deployment}}, {{release}}`)
+	err = WriteHelmValues(&bytes.Buffer{}, testHelmSummary, "{{container}}..resources", QoSGuaranteed)
+	assert.EqualError(t, err, `invalid helm values path "{{container}}..resources": empty key`)
+
+	// there are no burstable recommendations, so no releases
+	got = &bytes.Buffer{}
+	assert.NoError(t, WriteHelmValues(got, testHelmSummary, DefaultHelmValuesPath, QoSBurstable))
+	assert.Empty(t, got.String())
+}
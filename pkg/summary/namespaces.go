@@ -0,0 +1,119 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+	"github.com/fairwindsops/goldilocks/pkg/vpa"
+)
+
+// Reasons a namespace is in the list of goldilocks namespaces
+const (
+	// ReasonNamespaceEnabled is a namespace whose enabled label is true
+	ReasonNamespaceEnabled = "NamespaceEnabled"
+	// ReasonNamespaceIncluded is a namespace in the included namespaces of the controller
+	ReasonNamespaceIncluded = "NamespaceIncluded"
+	// ReasonOnByDefault is a namespace without a label or exclusion, when the controller is on by default
+	ReasonOnByDefault = "OnByDefault"
+	// ReasonGoldilocksVPAs is a namespace that the controller does not manage, but that has goldilocks VPAs
+	ReasonGoldilocksVPAs = "GoldilocksVPAs"
+)
+
+// ManagedNamespace is a namespace that the controller manages or that has goldilocks VPAs
+type ManagedNamespace struct {
+	Namespace string `json:"namespace"`
+
+	// Reason is why the namespace is in the list
+	Reason string `json:"reason"`
+
+	// Workloads is the number of workloads in the namespace with a goldilocks VPA
+	Workloads int `json:"workloads"`
+}
+
+// GetManagedNamespaces returns the namespaces that the controller manages, with the same rules as the controller,
+// and those that have VPAs with the Summarizer's VPA labels, sorted by name
+func (s Summarizer) GetManagedNamespaces() ([]ManagedNamespace, error) {
+	if err := s.loadFiles(); err != nil {
+		return nil, err
+	}
+
+	namespaces, err := s.listNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	vpas, err := s.listVPAs(getVPAListOptionsForLabels(s.vpaLabels))
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := map[string]int{}
+	for _, v := range vpas {
+		workloads[v.Namespace]++
+	}
+
+	reconciler := vpa.Reconciler{
+		OnByDefault:       s.onByDefault,
+		IncludeNamespaces: s.includeNamespaces,
+		ExcludeNamespaces: s.excludeNamespaces,
+	}
+	managed := []ManagedNamespace{}
+	for _, ns := range namespaces {
+		nsManaged := ManagedNamespace{
+			Namespace: ns.Name,
+			Workloads: workloads[ns.Name],
+		}
+		switch {
+		case reconciler.NamespaceIsManaged(&ns):
+			nsManaged.Reason = managedReason(ns, s.includeNamespaces)
+		case nsManaged.Workloads > 0:
+			nsManaged.Reason = ReasonGoldilocksVPAs
+		default:
+			klog.V(4).Infof("Namespace/%s is not managed and has no goldilocks VPAs", ns.Name)
+			continue
+		}
+		managed = append(managed, nsManaged)
+	}
+
+	sort.Slice(managed, func(i, j int) bool {
+		return managed[i].Namespace < managed[j].Namespace
+	})
+	return managed, nil
+}
+
+// managedReason returns why the controller manages the namespace, which has an enabled label of true,
+// is one of the included namespaces, or is otherwise managed by default
+func managedReason(namespace corev1.Namespace, includeNamespaces []string) string {
+	for k, v := range namespace.Labels {
+		if strings.ToLower(k) != utils.VpaEnabledLabel {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(v); err == nil && enabled {
+			return ReasonNamespaceEnabled
+		}
+	}
+	for _, included := range includeNamespaces {
+		if namespace.Name == included {
+			return ReasonNamespaceIncluded
+		}
+	}
+	return ReasonOnByDefault
+}
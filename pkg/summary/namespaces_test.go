@@ -0,0 +1,69 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func TestGetManagedNamespaces(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "enabled", Labels: map[string]string{utils.VpaEnabledLabel: "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Labels: map[string]string{utils.VpaEnabledLabel: "false"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "included"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "excluded"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "leftover"}},
+	}
+	for _, ns := range namespaces {
+		_, err := kubeClient.Client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	createTestWorkload(t, kubeClient, kubeClientVPA, "enabled", "one")
+	createTestWorkload(t, kubeClient, kubeClientVPA, "enabled", "two")
+	createTestWorkload(t, kubeClient, kubeClientVPA, "included", "app")
+	createTestWorkload(t, kubeClient, kubeClientVPA, "leftover", "app")
+
+	summarizer := NewSummarizer(ForManagedNamespaces(false, []string{"included"}, []string{"excluded"}))
+	got, err := summarizer.GetManagedNamespaces()
+	assert.NoError(t, err)
+	assert.Equal(t, []ManagedNamespace{
+		{Namespace: "enabled", Reason: ReasonNamespaceEnabled, Workloads: 2},
+		{Namespace: "included", Reason: ReasonNamespaceIncluded, Workloads: 1},
+		{Namespace: "leftover", Reason: ReasonGoldilocksVPAs, Workloads: 1},
+	}, got)
+
+	// on by default, every namespace without a label that is not excluded is managed
+	summarizer = NewSummarizer(ForManagedNamespaces(true, []string{"included"}, []string{"excluded"}))
+	got, err = summarizer.GetManagedNamespaces()
+	assert.NoError(t, err)
+	assert.Equal(t, []ManagedNamespace{
+		{Namespace: "enabled", Reason: ReasonNamespaceEnabled, Workloads: 2},
+		{Namespace: "included", Reason: ReasonNamespaceIncluded, Workloads: 1},
+		{Namespace: "leftover", Reason: ReasonOnByDefault, Workloads: 1},
+		{Namespace: "plain", Reason: ReasonOnByDefault, Workloads: 0},
+	}, got)
+}
@@ -15,16 +15,27 @@ type options struct {
 	namespace          string
 	vpaLabels          map[string]string
 	excludedContainers sets.String
+	pricing            *Pricing
+	rounding           utils.RoundingPolicy
+	policy             *RecommendationPolicy
+	files              []string
+	cache              *Cache
+	pageSize           int64
+	efficiency         *EfficiencyPolicy
+
+	// the namespaces managed by the controller, for the coverage report
+	onByDefault       bool
+	includeNamespaces []string
+	excludeNamespaces []string
 }
 
 // defaultOptions for a Summarizer
 func defaultOptions() *options {
 	return &options{
-		kubeClient:         kube.GetInstance(),
-		vpaClient:          kube.GetVPAInstance(),
 		namespace:          namespaceAllNamespaces,
 		vpaLabels:          utils.VPALabels,
 		excludedContainers: sets.NewString(),
+		pageSize:           defaultPageSize,
 	}
 }
 
@@ -48,3 +59,66 @@ func ForVPAsWithLabels(vpaLabels map[string]string) Option {
 		opts.vpaLabels = vpaLabels
 	}
 }
+
+// WithPricing is an Option for estimating the cost of each workload and namespace in the summary
+func WithPricing(pricing *Pricing) Option {
+	return func(opts *options) {
+		opts.pricing = pricing
+	}
+}
+
+// WithRounding is an Option for rounding the suggested requests and limits in the summary
+func WithRounding(policy utils.RoundingPolicy) Option {
+	return func(opts *options) {
+		opts.rounding = policy
+	}
+}
+
+// WithRecommendationPolicy is an Option for suggesting requests and limits calculated by the policy.
+// Namespaces and workloads can override it with the recommendation-policy annotation.
+func WithRecommendationPolicy(policy *RecommendationPolicy) Option {
+	return func(opts *options) {
+		opts.policy = policy
+	}
+}
+
+// FromFiles is an Option for summarizing the VPAs and workloads in YAML or JSON manifest files and directories,
+// such as `kubectl get -o yaml` output or a cluster dump, instead of a cluster
+func FromFiles(paths ...string) Option {
+	return func(opts *options) {
+		opts.files = paths
+	}
+}
+
+// WithCache is an Option for reading the summarized objects from a started Cache instead of listing them for each summary
+func WithCache(cache *Cache) Option {
+	return func(opts *options) {
+		opts.cache = cache
+	}
+}
+
+// WithPageSize is an Option for the number of VPAs and Deployments listed at a time, when they are not read from a Cache
+func WithPageSize(pageSize int64) Option {
+	return func(opts *options) {
+		opts.pageSize = pageSize
+	}
+}
+
+// WithEfficiency is an Option for the weights and grades of the efficiency scores in the summary,
+// which use the DefaultEfficiencyPolicy without it
+func WithEfficiency(policy *EfficiencyPolicy) Option {
+	return func(opts *options) {
+		opts.efficiency = policy
+	}
+}
+
+// ForManagedNamespaces is an Option for the namespaces that the controller manages in the coverage report, which
+// are those with the enabled label, then the included namespaces, and then every namespace that is not excluded
+// when the controller is on by default
+func ForManagedNamespaces(onByDefault bool, includeNamespaces, excludeNamespaces []string) Option {
+	return func(opts *options) {
+		opts.onByDefault = onByDefault
+		opts.includeNamespaces = includeNamespaces
+		opts.excludeNamespaces = excludeNamespaces
+	}
+}
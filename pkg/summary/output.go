@@ -0,0 +1,323 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats for writing a Summary
+const (
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	OutputTable    = "table"
+	OutputMarkdown = "markdown"
+	OutputHelm     = "helm"
+)
+
+// OutputFormats are all of the supported output formats
+var OutputFormats = []string{
+	OutputJSON,
+	OutputYAML,
+	OutputCSV,
+	OutputTable,
+	OutputMarkdown,
+	OutputHelm,
+}
+
+// containerRow is a single container of a Summary, flattened for tabular output
+type containerRow struct {
+	namespace  string
+	workload   string
+	replicas   int32
+	status     string
+	vpaCreated metav1.Time
+	container  ContainerSummary
+}
+
+// rowColumn is a column of the tabular output formats
+type rowColumn struct {
+	header string
+	value  func(containerRow) string
+}
+
+// rowColumns are the columns of the tabular output formats, in order
+var rowColumns = []rowColumn{
+	{"namespace", func(r containerRow) string { return r.namespace }},
+	{"workload", func(r containerRow) string { return r.workload }},
+	{"container", func(r containerRow) string { return r.container.ContainerName }},
+	{"cpuRequest", func(r containerRow) string { return quantityString(r.container.Requests, corev1.ResourceCPU) }},
+	{"cpuLimit", func(r containerRow) string { return quantityString(r.container.Limits, corev1.ResourceCPU) }},
+	{"cpuTarget", func(r containerRow) string { return quantityString(r.container.Target, corev1.ResourceCPU) }},
+	{"cpuLowerBound", func(r containerRow) string { return quantityString(r.container.LowerBound, corev1.ResourceCPU) }},
+	{"cpuUpperBound", func(r containerRow) string { return quantityString(r.container.UpperBound, corev1.ResourceCPU) }},
+	{"memoryRequest", func(r containerRow) string { return quantityString(r.container.Requests, corev1.ResourceMemory) }},
+	{"memoryLimit", func(r containerRow) string { return quantityString(r.container.Limits, corev1.ResourceMemory) }},
+	{"memoryTarget", func(r containerRow) string { return quantityString(r.container.Target, corev1.ResourceMemory) }},
+	{"memoryLowerBound", func(r containerRow) string { return quantityString(r.container.LowerBound, corev1.ResourceMemory) }},
+	{"memoryUpperBound", func(r containerRow) string { return quantityString(r.container.UpperBound, corev1.ResourceMemory) }},
+	// columns added after the first release are appended, so that existing columns keep their position
+	{"replicas", func(r containerRow) string { return strconv.Itoa(int(r.replicas)) }},
+	{"cpuDelta", func(r containerRow) string { return deltaQuantityString(r.container.Delta, corev1.ResourceCPU) }},
+	{"memoryDelta", func(r containerRow) string { return deltaQuantityString(r.container.Delta, corev1.ResourceMemory) }},
+	{"status", func(r containerRow) string { return r.status }},
+	{"age", func(r containerRow) string { return vpaAge(r.vpaCreated) }},
+	{"policyCpuRequest", policyValue(true, corev1.ResourceCPU)},
+	{"policyCpuLimit", policyValue(false, corev1.ResourceCPU)},
+	{"policyMemoryRequest", policyValue(true, corev1.ResourceMemory)},
+	{"policyMemoryLimit", policyValue(false, corev1.ResourceMemory)},
+}
+
+// Write writes the Summary to the writer in the given output format.
+// The helm format sets the guaranteed recommendation at the DefaultHelmValuesPath, see WriteHelmValues to choose them.
+func Write(w io.Writer, data Summary, format string) error {
+	switch format {
+	case OutputJSON:
+		summaryJSON, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(summaryJSON))
+		return err
+	case OutputYAML:
+		summaryYAML, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(summaryYAML)
+		return err
+	case OutputCSV:
+		return writeCSV(w, data.rows())
+	case OutputTable:
+		if err := writeTable(w, data.rows()); err != nil {
+			return err
+		}
+		return writeDeltaTotals(w, data)
+	case OutputMarkdown:
+		return writeMarkdown(w, data.rows())
+	case OutputHelm:
+		return WriteHelmValues(w, data, DefaultHelmValuesPath, QoSGuaranteed)
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", format, strings.Join(OutputFormats, ", "))
+	}
+}
+
+// rows flattens the Summary into one row per container, sorted by namespace, workload and container.
+// Workloads without any containers, such as those with no recommendation yet, get a single row without a container.
+func (s Summary) rows() []containerRow {
+	rows := []containerRow{}
+	for _, nsName := range sortedKeys(s.Namespaces) {
+		nsSummary := s.Namespaces[nsName]
+		for _, dName := range sortedKeys(nsSummary.Deployments) {
+			dSummary := nsSummary.Deployments[dName]
+			workloadRow := containerRow{
+				namespace:  nsSummary.Namespace,
+				workload:   dSummary.DeploymentName,
+				replicas:   dSummary.Replicas,
+				status:     dSummary.Status,
+				vpaCreated: dSummary.VPACreationTimestamp,
+			}
+			if len(dSummary.Containers) <= 0 {
+				rows = append(rows, workloadRow)
+				continue
+			}
+			for _, cName := range sortedKeys(dSummary.Containers) {
+				row := workloadRow
+				row.container = dSummary.Containers[cName]
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+func writeCSV(w io.Writer, rows []containerRow) error {
+	csvWriter := csv.NewWriter(w)
+	headers := make([]string, 0, len(rowColumns))
+	for _, column := range rowColumns {
+		headers = append(headers, column.header)
+	}
+	if err := csvWriter.Write(headers); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := csvWriter.Write(rowValues(row, "")); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeTable(w io.Writer, rows []containerRow) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	headers := make([]string, 0, len(rowColumns))
+	for _, column := range rowColumns {
+		headers = append(headers, strings.ToUpper(splitCamelCase(column.header)))
+	}
+	if _, err := fmt.Fprintln(tabWriter, strings.Join(headers, "\t")); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(tabWriter, strings.Join(rowValues(row, "-"), "\t")); err != nil {
+			return err
+		}
+	}
+	return tabWriter.Flush()
+}
+
+// writeDeltaTotals writes how over-provisioned each namespace and the whole cluster are,
+// along with the estimated monthly savings when pricing is configured
+func writeDeltaTotals(w io.Writer, data Summary) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tabWriter); err != nil {
+		return err
+	}
+	for _, nsName := range sortedKeys(data.Namespaces) {
+		nsSummary := data.Namespaces[nsName]
+		if _, err := fmt.Fprintf(tabWriter, "Namespace %s:\t%s%s\n", nsName, DeltaString(nsSummary.Delta), savingsString(nsSummary.Cost)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(tabWriter, "Total:\t%s%s\n", DeltaString(data.Delta), savingsString(data.Cost)); err != nil {
+		return err
+	}
+	return tabWriter.Flush()
+}
+
+// savingsString describes the monthly savings of the cost, or is empty without a cost
+func savingsString(c *Cost) string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf(", $%.2f/month savings", c.Savings)
+}
+
+func writeMarkdown(w io.Writer, rows []containerRow) error {
+	headers := make([]string, 0, len(rowColumns))
+	separators := make([]string, 0, len(rowColumns))
+	for _, column := range rowColumns {
+		header := splitCamelCase(column.header)
+		headers = append(headers, strings.ToUpper(header[:1])+header[1:])
+		separators = append(separators, "---")
+	}
+	lines := []string{
+		"| " + strings.Join(headers, " | ") + " |",
+		"| " + strings.Join(separators, " | ") + " |",
+	}
+	for _, row := range rows {
+		lines = append(lines, "| "+strings.Join(rowValues(row, "-"), " | ")+" |")
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// rowValues returns the values of each column for the row, with empty values replaced by the placeholder
+func rowValues(row containerRow, placeholder string) []string {
+	values := make([]string, 0, len(rowColumns))
+	for _, column := range rowColumns {
+		value := column.value(row)
+		if value == "" {
+			value = placeholder
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// quantityString returns the named quantity from the list, or an empty string if it is not set
+func quantityString(rl corev1.ResourceList, name corev1.ResourceName) string {
+	quant, ok := rl[name]
+	if !ok || quant.IsZero() {
+		return ""
+	}
+	return quant.String()
+}
+
+// deltaQuantityString returns the named quantity from the list, or an empty string if it is not set.
+// Unlike quantityString, a zero delta is shown as it means the request matches the recommendation.
+func deltaQuantityString(rl corev1.ResourceList, name corev1.ResourceName) string {
+	quant, ok := rl[name]
+	if !ok {
+		return ""
+	}
+	return quant.String()
+}
+
+// policyValue returns the value of a column for the named request or limit of the policy recommendation,
+// which is empty if there is no policy or it does not set the resource
+func policyValue(request bool, name corev1.ResourceName) func(containerRow) string {
+	return func(r containerRow) string {
+		if r.container.Policy == nil {
+			return ""
+		}
+		if request {
+			return quantityString(r.container.Policy.Requests, name)
+		}
+		return quantityString(r.container.Policy.Limits, name)
+	}
+}
+
+// splitCamelCase splits a camelCase header into lower case words, e.g. cpuLowerBound becomes "cpu lower bound"
+func splitCamelCase(header string) string {
+	var words strings.Builder
+	for i, r := range header {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				words.WriteRune(' ')
+			}
+			r += 'a' - 'A'
+		}
+		words.WriteRune(r)
+	}
+	return words.String()
+}
+
+// sortedKeys returns the keys of a summary map in order
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch typed := m.(type) {
+	case map[string]NamespaceSummary:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]DeploymentSummary:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case map[string]ContainerSummary:
+		for k := range typed {
+			keys = append(keys, k)
+		}
+	case corev1.ResourceList:
+		for k := range typed {
+			keys = append(keys, string(k))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var testOutputSummary = Summary{
+	APIVersion: APIVersion,
+	Namespaces: map[string]NamespaceSummary{
+		"testing": {
+			Namespace: "testing",
+			Delta:     corev1.ResourceList{"cpu": resource.MustParse("150m"), "memory": resource.MustParse("256Mi")},
+			Deployments: map[string]DeploymentSummary{
+				"app": {
+					DeploymentName:       "app",
+					Replicas:             2,
+					Status:               StatusRecommendationProvided,
+					VPACreationTimestamp: metav1.NewTime(time.Now().Add(-5 * 24 * time.Hour)),
+					Delta:                corev1.ResourceList{"cpu": resource.MustParse("150m"), "memory": resource.MustParse("256Mi")},
+					Containers: map[string]ContainerSummary{
+						"web": {
+							ContainerName: "web",
+							LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("10m"), "memory": resource.MustParse("64Mi")},
+							UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("1Gi")},
+							Target:        corev1.ResourceList{"cpu": resource.MustParse("25m"), "memory": resource.MustParse("128Mi")},
+							Requests:      corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("256Mi")},
+							Limits:        corev1.ResourceList{"memory": resource.MustParse("256Mi")},
+							Delta:         corev1.ResourceList{"cpu": resource.MustParse("75m"), "memory": resource.MustParse("128Mi")},
+							Policy: &Recommendation{
+								Requests:  corev1.ResourceList{"cpu": resource.MustParse("28m"), "memory": resource.MustParse("141M")},
+								Limits:    corev1.ResourceList{"memory": resource.MustParse("1289M")},
+								Unlimited: []corev1.ResourceName{"cpu"},
+							},
+						},
+						"sidecar": {
+							ContainerName: "sidecar",
+							Target:        corev1.ResourceList{"cpu": resource.MustParse("5m")},
+						},
+					},
+				},
+				"worker": {
+					DeploymentName:       "worker",
+					Replicas:             1,
+					Status:               StatusNoRecommendationYet,
+					VPACreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+					Containers:           map[string]ContainerSummary{},
+				},
+			},
+		},
+	},
+	Delta: corev1.ResourceList{"cpu": resource.MustParse("150m"), "memory": resource.MustParse("256Mi")},
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: OutputCSV,
+			want: `namespace,workload,container,cpuRequest,cpuLimit,cpuTarget,cpuLowerBound,cpuUpperBound,memoryRequest,memoryLimit,memoryTarget,memoryLowerBound,memoryUpperBound,replicas,cpuDelta,memoryDelta,status,age,policyCpuRequest,policyCpuLimit,policyMemoryRequest,policyMemoryLimit
+testing,app,sidecar,,,5m,,,,,,,,2,,,RecommendationProvided,5d,,,,
+testing,app,web,100m,,25m,10m,1,256Mi,256Mi,128Mi,64Mi,1Gi,2,75m,128Mi,RecommendationProvided,5d,28m,,141M,1289M
+testing,worker,,,,,,,,,,,,1,,,NoRecommendationYet,2m,,,,
+`,
+		},
+		{
+			format: OutputMarkdown,
+			want: `| Namespace | Workload | Container | Cpu request | Cpu limit | Cpu target | Cpu lower bound | Cpu upper bound | Memory request | Memory limit | Memory target | Memory lower bound | Memory upper bound | Replicas | Cpu delta | Memory delta | Status | Age | Policy cpu request | Policy cpu limit | Policy memory request | Policy memory limit |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| testing | app | sidecar | - | - | 5m | - | - | - | - | - | - | - | 2 | - | - | RecommendationProvided | 5d | - | - | - | - |
+| testing | app | web | 100m | - | 25m | 10m | 1 | 256Mi | 256Mi | 128Mi | 64Mi | 1Gi | 2 | 75m | 128Mi | RecommendationProvided | 5d | 28m | - | 141M | 1289M |
+| testing | worker | - | - | - | - | - | - | - | - | - | - | - | 1 | - | - | NoRecommendationYet | 2m | - | - | - | - |
+`,
+		},
+		{
+			format: OutputTable,
+			want: `NAMESPACE   WORKLOAD   CONTAINER   CPU REQUEST   CPU LIMIT   CPU TARGET   CPU LOWER BOUND   CPU UPPER BOUND   MEMORY REQUEST   MEMORY LIMIT   MEMORY TARGET   MEMORY LOWER BOUND   MEMORY UPPER BOUND   REPLICAS   CPU DELTA   MEMORY DELTA   STATUS                   AGE   POLICY CPU REQUEST   POLICY CPU LIMIT   POLICY MEMORY REQUEST   POLICY MEMORY LIMIT
+testing     app        sidecar     -             -           5m           -                 -                 -                -              -               -                    -                    2          -           -              RecommendationProvided   5d    -                    -                  -                       -
+testing     app        web         100m          -           25m          10m               1                 256Mi            256Mi          128Mi           64Mi                 1Gi                  2          75m         128Mi          RecommendationProvided   5d    28m                  -                  141M                    1289M
+testing     worker     -           -             -           -            -                 -                 -                -              -               -                    -                    1          -           -              NoRecommendationYet      2m    -                    -                  -                       -
+
+Namespace testing:   0.15 cores / 0.25 GiB over-provisioned
+Total:               0.15 cores / 0.25 GiB over-provisioned
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			assert.NoError(t, Write(got, testOutputSummary, tt.format))
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	for _, format := range []string{OutputJSON, OutputYAML} {
+		t.Run(format, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			assert.NoError(t, Write(got, testOutputSummary, format))
+
+			// yaml is a superset of json, so both can be read back the same way
+			roundTrip := Summary{}
+			assert.NoError(t, yaml.Unmarshal(got.Bytes(), &roundTrip))
+			assert.Equal(t, APIVersion, roundTrip.APIVersion)
+			target := roundTrip.Namespaces["testing"].Deployments["app"].Containers["web"].Target
+			assert.Equal(t, "128Mi", target.Memory().String())
+		})
+	}
+}
+
+func TestWriteUnsupported(t *testing.T) {
+	assert.EqualError(t, Write(&bytes.Buffer{}, testOutputSummary, "xml"), `unsupported output format "xml", must be one of: json, yaml, csv, table, markdown, helm`)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Recommendations that patches can set container resources to
+const (
+	QoSGuaranteed = "guaranteed"
+	QoSBurstable  = "burstable"
+	QoSPolicy     = "policy"
+)
+
+// QoSClasses are all of the recommendations that patches can be generated from
+var QoSClasses = []string{
+	QoSGuaranteed,
+	QoSBurstable,
+	QoSPolicy,
+}
+
+// Formats for writing a WorkloadPatch
+const (
+	PatchStrategic = "strategic"
+	PatchKustomize = "kustomize"
+	PatchKubectl   = "kubectl"
+)
+
+// PatchFormats are all of the supported patch formats
+var PatchFormats = []string{
+	PatchStrategic,
+	PatchKustomize,
+	PatchKubectl,
+}
+
+// WorkloadPatch is a strategic merge patch that sets the container resources of a workload to a recommendation
+type WorkloadPatch struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	// the patch body, which only sets the resources of each container by name
+	spec map[string]interface{}
+}
+
+// Patches returns a patch for each workload in the Summary that sets its container resources to the chosen recommendation,
+// sorted by namespace and workload. Containers without that recommendation, and workloads without any such containers, are left out.
+func Patches(data Summary, qos string) ([]WorkloadPatch, error) {
+	if err := validateQoS(qos); err != nil {
+		return nil, err
+	}
+
+	patches := []WorkloadPatch{}
+	for _, nsName := range sortedKeys(data.Namespaces) {
+		nsSummary := data.Namespaces[nsName]
+		for _, dName := range sortedKeys(nsSummary.Deployments) {
+			dSummary := nsSummary.Deployments[dName]
+
+			containers := []interface{}{}
+			for _, cName := range sortedKeys(dSummary.Containers) {
+				resources := qosResourceRequirements(dSummary.Containers[cName], qos)
+				if resources == nil {
+					continue
+				}
+				containers = append(containers, map[string]interface{}{
+					"name":      cName,
+					"resources": resources,
+				})
+			}
+			if len(containers) <= 0 {
+				continue
+			}
+
+			patches = append(patches, WorkloadPatch{
+				Kind:      "Deployment",
+				Namespace: nsSummary.Namespace,
+				Name:      dSummary.DeploymentName,
+				spec: map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containers": containers,
+						},
+					},
+				},
+			})
+		}
+	}
+	return patches, nil
+}
+
+func validateQoS(qos string) error {
+	switch qos {
+	case QoSGuaranteed, QoSBurstable, QoSPolicy:
+		return nil
+	default:
+		return fmt.Errorf("unsupported recommendation %q, must be one of: %s", qos, strings.Join(QoSClasses, ", "))
+	}
+}
+
+// qosRecommendation returns the chosen recommendation of the container, or nil if it does not have one
+func qosRecommendation(cSummary ContainerSummary, qos string) *Recommendation {
+	switch qos {
+	case QoSGuaranteed:
+		return &cSummary.Guaranteed
+	case QoSBurstable:
+		return &cSummary.Burstable
+	default:
+		return cSummary.Policy
+	}
+}
+
+// qosResourceRequirements returns the requests and limits of the chosen recommendation of the container,
+// in the structure of container resources, or nil if it does not have one.
+// Limits the recommendation leaves unlimited are set to null, which removes them in a strategic merge patch.
+func qosResourceRequirements(cSummary ContainerSummary, qos string) map[string]map[corev1.ResourceName]interface{} {
+	rec := qosRecommendation(cSummary, qos)
+	if rec == nil || (len(rec.Requests) <= 0 && len(rec.Limits) <= 0 && len(rec.Unlimited) <= 0) {
+		return nil
+	}
+	resources := map[string]map[corev1.ResourceName]interface{}{}
+	if len(rec.Requests) > 0 {
+		resources["requests"] = map[corev1.ResourceName]interface{}{}
+		for name, quant := range rec.Requests {
+			resources["requests"][name] = quant
+		}
+	}
+	if len(rec.Limits) > 0 || len(rec.Unlimited) > 0 {
+		resources["limits"] = map[corev1.ResourceName]interface{}{}
+		for name, quant := range rec.Limits {
+			resources["limits"][name] = quant
+		}
+		for _, name := range rec.Unlimited {
+			resources["limits"][name] = nil
+		}
+	}
+	return resources
+}
+
+// FileName is the name of the file to write the patch to in the given format, which is unique within a Summary
+func (p WorkloadPatch) FileName(format string) string {
+	extension := "yaml"
+	if format == PatchKubectl {
+		extension = "sh"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", p.Namespace, strings.ToLower(p.Kind), p.Name, extension)
+}
+
+// Write writes the patch to the writer in the given format: a strategic merge patch headed by a comment
+// naming the workload it applies to, a kustomize patch that also identifies the workload, or a kubectl patch command
+func (p WorkloadPatch) Write(w io.Writer, format string) error {
+	switch format {
+	case PatchStrategic:
+		if _, err := fmt.Fprintf(w, "# %s %s in namespace %s\n", p.Kind, p.Name, p.Namespace); err != nil {
+			return err
+		}
+		return writeYAML(w, map[string]interface{}{"spec": p.spec})
+	case PatchKustomize:
+		return writeYAML(w, map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       p.Kind,
+			"metadata": map[string]interface{}{
+				"name":      p.Name,
+				"namespace": p.Namespace,
+			},
+			"spec": p.spec,
+		})
+	case PatchKubectl:
+		patchJSON, err := json.Marshal(map[string]interface{}{"spec": p.spec})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "kubectl patch %s %s --namespace %s --type strategic --patch '%s'\n", strings.ToLower(p.Kind), p.Name, p.Namespace, patchJSON)
+		return err
+	default:
+		return fmt.Errorf("unsupported patch format %q, must be one of: %s", format, strings.Join(PatchFormats, ", "))
+	}
+}
+
+func writeYAML(w io.Writer, obj interface{}) error {
+	objYAML, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(objYAML)
+	return err
+}
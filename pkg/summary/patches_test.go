@@ -0,0 +1,140 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPatches(t *testing.T) {
+	patches, err := Patches(testOutputSummary, QoSPolicy)
+	assert.NoError(t, err)
+	// the sidecar has no policy recommendation and the worker has no containers
+	if assert.Len(t, patches, 1) {
+		assert.Equal(t, "testing-deployment-app.yaml", patches[0].FileName(PatchStrategic))
+		assert.Equal(t, "testing-deployment-app.sh", patches[0].FileName(PatchKubectl))
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: PatchStrategic,
+			want: `# Deployment app in namespace testing
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          limits:
+            cpu: null
+            memory: 1289M
+          requests:
+            cpu: 28m
+            memory: 141M
+`,
+		},
+		{
+			format: PatchKustomize,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: testing
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          limits:
+            cpu: null
+            memory: 1289M
+          requests:
+            cpu: 28m
+            memory: 141M
+`,
+		},
+		{
+			format: PatchKubectl,
+			want: `kubectl patch deployment app --namespace testing --type strategic --patch '{"spec":{"template":{"spec":{"containers":[{"name":"web","resources":{"limits":{"cpu":null,"memory":"1289M"},"requests":{"cpu":"28m","memory":"141M"}}}]}}}}'
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			assert.NoError(t, patches[0].Write(got, tt.format))
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	assert.Error(t, patches[0].Write(&bytes.Buffer{}, "json"))
+}
+
+func TestPatchesGuaranteed(t *testing.T) {
+	data := Summary{
+		Namespaces: map[string]NamespaceSummary{
+			"testing": {
+				Namespace: "testing",
+				Deployments: map[string]DeploymentSummary{
+					"app": {
+						DeploymentName: "app",
+						Containers: map[string]ContainerSummary{
+							"web": {
+								ContainerName: "web",
+								Guaranteed:    guaranteedRecommendation(corev1.ResourceList{"cpu": resource.MustParse("25m")}),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	patches, err := Patches(data, QoSGuaranteed)
+	assert.NoError(t, err)
+	if assert.Len(t, patches, 1) {
+		got := &bytes.Buffer{}
+		assert.NoError(t, patches[0].Write(got, PatchStrategic))
+		assert.Equal(t, `# Deployment app in namespace testing
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          limits:
+            cpu: 25m
+          requests:
+            cpu: 25m
+`, got.String())
+	}
+
+	// there is no burstable recommendation
+	patches, err = Patches(data, QoSBurstable)
+	assert.NoError(t, err)
+	assert.Empty(t, patches)
+
+	_, err = Patches(data, "besteffort")
+	assert.EqualError(t, err, `unsupported recommendation "besteffort", must be one of: guaranteed, burstable, policy`)
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// Bases of a PolicyValue, which are the VPA recommendation it is calculated from
+const (
+	BasisTarget     = "target"
+	BasisLowerBound = "lowerBound"
+	BasisUpperBound = "upperBound"
+	// BasisNone leaves the value unset, e.g. for no cpu limit
+	BasisNone = "none"
+)
+
+// PolicyValue is how a suggested request or limit is calculated from the VPA recommendation
+type PolicyValue struct {
+	Basis      string  `json:"basis"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+}
+
+// ResourcePolicy is how the suggested request and limit of a resource are calculated
+type ResourcePolicy struct {
+	Request PolicyValue `json:"request"`
+	Limit   PolicyValue `json:"limit"`
+}
+
+// RecommendationPolicy is how the suggested requests and limits of a container are calculated from its VPA recommendation.
+// Values without a basis are inherited from the policy it overrides, and otherwise requests use the target and limits are not set.
+type RecommendationPolicy struct {
+	CPU    ResourcePolicy `json:"cpu"`
+	Memory ResourcePolicy `json:"memory"`
+}
+
+// LoadRecommendationPolicy reads a RecommendationPolicy from a YAML or JSON file
+func LoadRecommendationPolicy(path string) (*RecommendationPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecommendationPolicy(data)
+}
+
+func parseRecommendationPolicy(data []byte) (*RecommendationPolicy, error) {
+	policy := &RecommendationPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, err
+	}
+	for name, value := range policy.values() {
+		if err := value.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return policy, nil
+}
+
+// values returns pointers to each value of the policy by name
+func (p *RecommendationPolicy) values() map[string]*PolicyValue {
+	return map[string]*PolicyValue{
+		"cpu request":    &p.CPU.Request,
+		"cpu limit":      &p.CPU.Limit,
+		"memory request": &p.Memory.Request,
+		"memory limit":   &p.Memory.Limit,
+	}
+}
+
+func (v PolicyValue) validate() error {
+	switch v.Basis {
+	case "", BasisTarget, BasisLowerBound, BasisUpperBound, BasisNone:
+	default:
+		return fmt.Errorf("unsupported basis %q, must be one of: %s, %s, %s, %s", v.Basis, BasisTarget, BasisLowerBound, BasisUpperBound, BasisNone)
+	}
+	if v.Multiplier < 0 {
+		return fmt.Errorf("multiplier must not be negative")
+	}
+	return nil
+}
+
+// merge returns a copy of the policy with every value that has a basis in the override replaced.
+// Either policy may be nil.
+func (p *RecommendationPolicy) merge(override *RecommendationPolicy) *RecommendationPolicy {
+	if p == nil && override == nil {
+		return nil
+	}
+	merged := &RecommendationPolicy{}
+	if p != nil {
+		*merged = *p
+	}
+	if override != nil {
+		overrideValues := override.values()
+		for name, value := range merged.values() {
+			if overrideValue := overrideValues[name]; overrideValue.Basis != "" {
+				*value = *overrideValue
+			}
+		}
+	}
+	return merged
+}
+
+// recommend returns the suggested requests and limits of a container with the VPA recommendation
+func (p RecommendationPolicy) recommend(target, lowerBound, upperBound corev1.ResourceList) Recommendation {
+	rec := Recommendation{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	resourcePolicies := map[corev1.ResourceName]ResourcePolicy{
+		corev1.ResourceCPU:    p.CPU,
+		corev1.ResourceMemory: p.Memory,
+	}
+	for _, name := range qosResources {
+		resourcePolicy := resourcePolicies[name]
+		request := resourcePolicy.Request
+		if request.Basis == "" {
+			request.Basis = BasisTarget
+		}
+		if quant, ok := request.value(name, target, lowerBound, upperBound); ok {
+			rec.Requests[name] = quant
+		}
+		if quant, ok := resourcePolicy.Limit.value(name, target, lowerBound, upperBound); ok {
+			rec.Limits[name] = quant
+		} else if resourcePolicy.Limit.Basis == BasisNone {
+			rec.Unlimited = append(rec.Unlimited, name)
+		}
+	}
+	return rec
+}
+
+// value returns the named resource of the recommendation the basis refers to, multiplied by the multiplier,
+// or false if the basis is none or the recommendation does not have the resource
+func (v PolicyValue) value(name corev1.ResourceName, target, lowerBound, upperBound corev1.ResourceList) (resource.Quantity, bool) {
+	var basis corev1.ResourceList
+	switch v.Basis {
+	case BasisTarget:
+		basis = target
+	case BasisLowerBound:
+		basis = lowerBound
+	case BasisUpperBound:
+		basis = upperBound
+	default:
+		return resource.Quantity{}, false
+	}
+	quant, ok := basis[name]
+	if !ok {
+		return resource.Quantity{}, false
+	}
+	if v.Multiplier == 0 || v.Multiplier == 1 {
+		return quant.DeepCopy(), true
+	}
+	return utils.ScaleQuantity(name, quant, v.Multiplier), true
+}
+
+// annotationPolicy returns the RecommendationPolicy in the annotations, or nil if there is none
+func annotationPolicy(annotations map[string]string) (*RecommendationPolicy, error) {
+	value, ok := annotations[utils.RecommendationPolicyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	return parseRecommendationPolicy([]byte(value))
+}
@@ -0,0 +1,212 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// testSREPolicy sets requests to the target plus 10%, memory limits to the upper bound plus 20%, and no cpu limits
+const testSREPolicy = `
+cpu:
+  request:
+    basis: target
+    multiplier: 1.1
+  limit:
+    basis: none
+memory:
+  request:
+    basis: target
+    multiplier: 1.1
+  limit:
+    basis: upperBound
+    multiplier: 1.2
+`
+
+func TestParseRecommendationPolicy(t *testing.T) {
+	policy, err := parseRecommendationPolicy([]byte(testSREPolicy))
+	assert.NoError(t, err)
+	assert.Equal(t, &RecommendationPolicy{
+		CPU: ResourcePolicy{
+			Request: PolicyValue{Basis: BasisTarget, Multiplier: 1.1},
+			Limit:   PolicyValue{Basis: BasisNone},
+		},
+		Memory: ResourcePolicy{
+			Request: PolicyValue{Basis: BasisTarget, Multiplier: 1.1},
+			Limit:   PolicyValue{Basis: BasisUpperBound, Multiplier: 1.2},
+		},
+	}, policy)
+
+	_, err = parseRecommendationPolicy([]byte(`{"cpu": {"request": {"basis": "average"}}}`))
+	assert.EqualError(t, err, `invalid cpu request: unsupported basis "average", must be one of: target, lowerBound, upperBound, none`)
+	_, err = parseRecommendationPolicy([]byte(`{"memory": {"limit": {"basis": "target", "multiplier": -1}}}`))
+	assert.EqualError(t, err, "invalid memory limit: multiplier must not be negative")
+	_, err = parseRecommendationPolicy([]byte(`{"gpu": {}}`))
+	assert.Error(t, err)
+}
+
+func TestRecommendationPolicyMerge(t *testing.T) {
+	var none *RecommendationPolicy
+	assert.Nil(t, none.merge(nil))
+
+	global := &RecommendationPolicy{
+		CPU: ResourcePolicy{Request: PolicyValue{Basis: BasisTarget, Multiplier: 1.1}},
+	}
+	override := &RecommendationPolicy{
+		CPU:    ResourcePolicy{Limit: PolicyValue{Basis: BasisUpperBound}},
+		Memory: ResourcePolicy{Request: PolicyValue{Basis: BasisLowerBound}},
+	}
+	assert.Equal(t, &RecommendationPolicy{
+		CPU: ResourcePolicy{
+			Request: PolicyValue{Basis: BasisTarget, Multiplier: 1.1},
+			Limit:   PolicyValue{Basis: BasisUpperBound},
+		},
+		Memory: ResourcePolicy{Request: PolicyValue{Basis: BasisLowerBound}},
+	}, global.merge(override))
+	assert.Equal(t, override, none.merge(override))
+
+	// merging copies rather than changing the policy
+	assert.Equal(t, PolicyValue{}, global.CPU.Limit)
+}
+
+func TestRecommendationPolicyRecommend(t *testing.T) {
+	policy, err := parseRecommendationPolicy([]byte(testSREPolicy))
+	assert.NoError(t, err)
+
+	got := policy.recommend(
+		corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("100M")},
+		corev1.ResourceList{"cpu": resource.MustParse("50m"), "memory": resource.MustParse("50M")},
+		corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("1G")},
+	)
+	gotJSON, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"110m","memory":"110M"},"limits":{"memory":"1200M"},"unlimited":["cpu"]}`, string(gotJSON))
+
+	// an empty policy requests the target and sets no limits
+	got = RecommendationPolicy{}.recommend(
+		corev1.ResourceList{"cpu": resource.MustParse("100m")},
+		corev1.ResourceList{},
+		corev1.ResourceList{},
+	)
+	gotJSON, err = json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"100m"},"limits":{}}`, string(gotJSON))
+}
+
+func TestSummarizerRecommendationPolicy(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	global, err := parseRecommendationPolicy([]byte(testSREPolicy))
+	assert.NoError(t, err)
+	summarizer := NewSummarizer(WithRecommendationPolicy(global))
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default-policy"}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name: "cpu-limits",
+			Annotations: map[string]string{
+				utils.RecommendationPolicyAnnotation: `{"cpu": {"limit": {"basis": "upperBound"}}}`,
+			},
+		}},
+	}
+	deployments := []*appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default-policy"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "cpu-limits"}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:      "lower",
+			Namespace: "cpu-limits",
+			Annotations: map[string]string{
+				utils.RecommendationPolicyAnnotation: `{"cpu": {"request": {"basis": "lowerBound"}}}`,
+			},
+		}},
+	}
+	for _, ns := range namespaces {
+		_, err := kubeClient.Client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	for _, d := range deployments {
+		d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "app"}}
+		_, err := kubeClient.Client.AppsV1().Deployments(d.Namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		vpa := &vpav1.VerticalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: d.Name, Namespace: d.Namespace, Labels: utils.VPALabels},
+			Spec: vpav1.VerticalPodAutoscalerSpec{
+				TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: d.Name},
+			},
+			Status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: &vpav1.RecommendedPodResources{
+					ContainerRecommendations: []vpav1.RecommendedContainerResources{
+						{
+							ContainerName: "app",
+							Target:        corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("100M")},
+							LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("50m"), "memory": resource.MustParse("50M")},
+							UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("1G")},
+						},
+					},
+				},
+			},
+		}
+		_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers(d.Namespace).Create(context.TODO(), vpa, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		namespace  string
+		deployment string
+		want       string
+	}{
+		{"default-policy", "app", `{"requests":{"cpu":"110m","memory":"110M"},"limits":{"memory":"1200M"},"unlimited":["cpu"]}`},
+		{"cpu-limits", "app", `{"requests":{"cpu":"110m","memory":"110M"},"limits":{"cpu":"1","memory":"1200M"}}`},
+		{"cpu-limits", "lower", `{"requests":{"cpu":"50m","memory":"110M"},"limits":{"cpu":"1","memory":"1200M"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace+"/"+tt.deployment, func(t *testing.T) {
+			policy := got.Namespaces[tt.namespace].Deployments[tt.deployment].Containers["app"].Policy
+			if assert.NotNil(t, policy) {
+				policyJSON, err := json.Marshal(policy)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.want, string(policyJSON))
+			}
+		})
+	}
+
+	// without a global policy or annotations, there is no policy recommendation
+	summarizer = NewSummarizer(ForNamespace("default-policy"))
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+	got, err = summarizer.GetSummary()
+	assert.NoError(t, err)
+	assert.Nil(t, got.Namespaces["default-policy"].Deployments["app"].Containers["app"].Policy)
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"io/ioutil"
+	"math"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// hoursPerMonth is the average number of hours in a month, used to turn hourly prices into monthly costs
+const hoursPerMonth = 730
+
+// UnitPrices are the hourly prices of a vCPU and a GiB of memory
+type UnitPrices struct {
+	CPUHourly       float64 `json:"cpuHourly"`
+	MemoryGiBHourly float64 `json:"memoryGiBHourly"`
+}
+
+// NodeLabelPrices are the unit prices of nodes with the labels
+type NodeLabelPrices struct {
+	Labels map[string]string `json:"labels"`
+	UnitPrices
+}
+
+// Pricing is the configuration for estimating the cost of workloads.
+// A workload is priced by the first node label entry its nodeSelector matches,
+// then by its namespace, and otherwise by the default unit prices.
+type Pricing struct {
+	UnitPrices
+	NodeLabels []NodeLabelPrices     `json:"nodeLabels"`
+	Namespaces map[string]UnitPrices `json:"namespaces"`
+}
+
+// Cost is the estimated monthly cost of the current requests and the recommended target
+type Cost struct {
+	Current     float64 `json:"current"`
+	Recommended float64 `json:"recommended"`
+	Savings     float64 `json:"savings"`
+}
+
+// LoadPricing reads a Pricing from a YAML or JSON file
+func LoadPricing(path string) (*Pricing, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pricing := &Pricing{}
+	if err := yaml.UnmarshalStrict(data, pricing); err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// pricesFor returns the unit prices of a workload in the namespace scheduled with the nodeSelector
+func (p Pricing) pricesFor(namespace string, nodeSelector map[string]string) UnitPrices {
+	for _, nodeLabelPrices := range p.NodeLabels {
+		if len(nodeLabelPrices.Labels) > 0 && labels.SelectorFromSet(nodeLabelPrices.Labels).Matches(labels.Set(nodeSelector)) {
+			return nodeLabelPrices.UnitPrices
+		}
+	}
+	if prices, ok := p.Namespaces[namespace]; ok {
+		return prices
+	}
+	return p.UnitPrices
+}
+
+// deploymentCost estimates the monthly cost of all replicas of the summarized containers of the Deployment
+func (p Pricing) deploymentCost(deployment *appsv1.Deployment, dSummary DeploymentSummary) *Cost {
+	f := deploymentFootprint(dSummary)
+	prices := p.pricesFor(deployment.Namespace, deployment.Spec.Template.Spec.NodeSelector)
+	c := &Cost{
+		Current:     prices.monthlyCost(f.TotalRequests),
+		Recommended: prices.monthlyCost(f.TotalTarget),
+	}
+	c.Savings = roundCents(c.Current - c.Recommended)
+	return c
+}
+
+// monthlyCost returns the cost of the cpu and memory in the list for a month
+func (u UnitPrices) monthlyCost(rl corev1.ResourceList) float64 {
+	cpu := rl[corev1.ResourceCPU]
+	memory := rl[corev1.ResourceMemory]
+	hourly := float64(cpu.MilliValue())/1000*u.CPUHourly + float64(memory.Value())/(1<<30)*u.MemoryGiBHourly
+	return roundCents(hourly * hoursPerMonth)
+}
+
+// addCost returns the sum of the costs, treating a nil total as zero
+func addCost(total, c *Cost) *Cost {
+	sum := &Cost{}
+	if total != nil {
+		*sum = *total
+	}
+	sum.Current = roundCents(sum.Current + c.Current)
+	sum.Recommended = roundCents(sum.Recommended + c.Recommended)
+	sum.Savings = roundCents(sum.Savings + c.Savings)
+	return sum
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
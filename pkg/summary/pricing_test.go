@@ -0,0 +1,140 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testPricing = Pricing{
+	UnitPrices: UnitPrices{CPUHourly: 0.04, MemoryGiBHourly: 0.005},
+	NodeLabels: []NodeLabelPrices{
+		{
+			Labels:     map[string]string{"node.kubernetes.io/lifecycle": "spot"},
+			UnitPrices: UnitPrices{CPUHourly: 0.01, MemoryGiBHourly: 0.001},
+		},
+	},
+	Namespaces: map[string]UnitPrices{
+		"discounted": {CPUHourly: 0.02, MemoryGiBHourly: 0.0025},
+	},
+}
+
+func TestLoadPricing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pricing")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pricing.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`cpuHourly: 0.04
+memoryGiBHourly: 0.005
+nodeLabels:
+- labels:
+    node.kubernetes.io/lifecycle: spot
+  cpuHourly: 0.01
+  memoryGiBHourly: 0.001
+namespaces:
+  discounted:
+    cpuHourly: 0.02
+    memoryGiBHourly: 0.0025
+`), 0644))
+
+	pricing, err := LoadPricing(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, testPricing, *pricing)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("cpuHourly: 0.04\ncpuPerHour: 1\n"), 0644))
+	_, err = LoadPricing(path)
+	assert.Error(t, err)
+
+	_, err = LoadPricing(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestPricesFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		namespace    string
+		nodeSelector map[string]string
+		want         UnitPrices
+	}{
+		{"default", "testing", nil, testPricing.UnitPrices},
+		{"namespace", "discounted", nil, testPricing.Namespaces["discounted"]},
+		{"node labels before namespace", "discounted", map[string]string{"node.kubernetes.io/lifecycle": "spot", "zone": "a"}, testPricing.NodeLabels[0].UnitPrices},
+		{"node labels not matched", "testing", map[string]string{"node.kubernetes.io/lifecycle": "on-demand"}, testPricing.UnitPrices},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, testPricing.pricesFor(tt.namespace, tt.nodeSelector))
+		})
+	}
+}
+
+func TestDeploymentCost(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "testing"}}
+	dSummary := DeploymentSummary{
+		DeploymentName: "app",
+		Replicas:       2,
+		Containers: map[string]ContainerSummary{
+			"app": {
+				ContainerName: "app",
+				Requests:      corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("2Gi")},
+				Target:        corev1.ResourceList{"cpu": resource.MustParse("250m"), "memory": resource.MustParse("1Gi")},
+			},
+		},
+	}
+
+	// current: 2 replicas * (1 cpu * 0.04 + 2 GiB * 0.005) * 730h = 73
+	// recommended: 2 replicas * (0.25 cpu * 0.04 + 1 GiB * 0.005) * 730h = 21.9
+	got := testPricing.deploymentCost(deployment, dSummary)
+	assert.Equal(t, &Cost{Current: 73, Recommended: 21.9, Savings: 51.1}, got)
+
+	// a resource without a request is not counted in either cost
+	unrequested := dSummary
+	unrequested.Containers = map[string]ContainerSummary{
+		"app": {
+			ContainerName: "app",
+			Requests:      corev1.ResourceList{"cpu": resource.MustParse("1")},
+			Target:        corev1.ResourceList{"cpu": resource.MustParse("250m"), "memory": resource.MustParse("1Gi")},
+		},
+	}
+	assert.Equal(t, &Cost{Current: 58.4, Recommended: 14.6, Savings: 43.8}, testPricing.deploymentCost(deployment, unrequested))
+
+	total := addCost(nil, got)
+	total = addCost(total, got)
+	assert.Equal(t, &Cost{Current: 146, Recommended: 43.8, Savings: 102.2}, total)
+
+	summary := Summary{
+		Namespaces: map[string]NamespaceSummary{
+			"testing": {Namespace: "testing", Cost: total},
+		},
+		Cost: total,
+	}
+	output := &bytes.Buffer{}
+	assert.NoError(t, writeDeltaTotals(output, summary))
+	assert.Equal(t, `
+Namespace testing:   0 cores / 0 GiB over-provisioned, $102.20/month savings
+Total:               0 cores / 0 GiB over-provisioned, $102.20/month savings
+`, output.String())
+}
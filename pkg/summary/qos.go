@@ -0,0 +1,95 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// Recommendation is a suggested set of container requests and limits
+type Recommendation struct {
+	Requests corev1.ResourceList `json:"requests"`
+	Limits   corev1.ResourceList `json:"limits"`
+	// Unlimited are the resources whose limit should be removed, because the policy has a limit basis of none
+	Unlimited []corev1.ResourceName `json:"unlimited,omitempty"`
+}
+
+// qosResources are the resources that VPA recommends, and so the only ones suggested
+var qosResources = []corev1.ResourceName{
+	corev1.ResourceCPU,
+	corev1.ResourceMemory,
+}
+
+// guaranteedRecommendation suggests requests and limits both equal to the target, for the Guaranteed QoS class
+func guaranteedRecommendation(target corev1.ResourceList) Recommendation {
+	return Recommendation{
+		Requests: qosResourceList(target),
+		Limits:   qosResourceList(target),
+	}
+}
+
+// burstableRecommendation suggests requests equal to the lower bound and limits equal to the upper bound, for the Burstable QoS class
+func burstableRecommendation(lowerBound, upperBound corev1.ResourceList) Recommendation {
+	return Recommendation{
+		Requests: qosResourceList(lowerBound),
+		Limits:   qosResourceList(upperBound),
+	}
+}
+
+// qosResourceList returns a copy of the recommended resources in the list
+func qosResourceList(rl corev1.ResourceList) corev1.ResourceList {
+	qosList := corev1.ResourceList{}
+	for _, name := range qosResources {
+		if quant, ok := rl[name]; ok {
+			qosList[name] = quant.DeepCopy()
+		}
+	}
+	return qosList
+}
+
+// uncoveredResources returns the requests and limits of resources that VPA does not recommend, such as ephemeral-storage
+// and extended resources like hugepages, or nil if the container has none
+func uncoveredResources(requests, limits corev1.ResourceList) *Recommendation {
+	uncovered := Recommendation{
+		Requests: uncoveredResourceList(requests),
+		Limits:   uncoveredResourceList(limits),
+	}
+	if len(uncovered.Requests) <= 0 && len(uncovered.Limits) <= 0 {
+		return nil
+	}
+	return &uncovered
+}
+
+// uncoveredResourceList returns a copy of the resources in the list that are not recommended
+func uncoveredResourceList(rl corev1.ResourceList) corev1.ResourceList {
+	uncoveredList := corev1.ResourceList{}
+	for name, quant := range rl {
+		if name != corev1.ResourceCPU && name != corev1.ResourceMemory {
+			uncoveredList[name] = quant.DeepCopy()
+		}
+	}
+	return uncoveredList
+}
+
+// rounded returns a copy of the recommendation with its requests and limits rounded by the policy
+func (r Recommendation) rounded(policy utils.RoundingPolicy) Recommendation {
+	return Recommendation{
+		Requests:  policy.RoundResourceList(r.Requests),
+		Limits:    policy.RoundResourceList(r.Limits),
+		Unlimited: r.Unlimited,
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+)
+
+// Recommendation statuses of a workload in the summary.
+// Workloads whose VPA has a ConfigUnsupported or NoPodsMatched condition have that condition type as their status instead.
+const (
+	StatusNoRecommendationYet    = "NoRecommendationYet"
+	StatusLowConfidence          = "LowConfidence"
+	StatusRecommendationProvided = "RecommendationProvided"
+)
+
+// blockingConditions are the VPA conditions that stop it from recommending, in order of precedence
+var blockingConditions = []vpav1.VerticalPodAutoscalerConditionType{
+	vpav1.ConfigUnsupported,
+	vpav1.NoPodsMatched,
+}
+
+// vpaStatus returns the recommendation status of the VPA and the message of the condition it comes from
+func vpaStatus(vpa vpav1.VerticalPodAutoscaler) (string, string) {
+	conditions := map[vpav1.VerticalPodAutoscalerConditionType]vpav1.VerticalPodAutoscalerCondition{}
+	for _, condition := range vpa.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			conditions[condition.Type] = condition
+		}
+	}
+
+	for _, conditionType := range blockingConditions {
+		if condition, ok := conditions[conditionType]; ok {
+			return string(conditionType), condition.Message
+		}
+	}
+	if vpa.Status.Recommendation == nil || len(vpa.Status.Recommendation.ContainerRecommendations) <= 0 {
+		return StatusNoRecommendationYet, conditions[vpav1.FetchingHistory].Message
+	}
+	if condition, ok := conditions[vpav1.LowConfidence]; ok {
+		return StatusLowConfidence, condition.Message
+	}
+	return StatusRecommendationProvided, conditions[vpav1.RecommendationProvided].Message
+}
+
+// vpaAge returns how long ago a VPA was created, in the same format as kubectl, e.g. "5d".
+// It is computed when the summary is written, so that cached summaries do not show a stale age.
+func vpaAge(created metav1.Time) string {
+	if created.IsZero() {
+		return ""
+	}
+	return duration.HumanDuration(time.Since(created.Time))
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func TestVPAStatus(t *testing.T) {
+	recommendation := &vpav1.RecommendedPodResources{
+		ContainerRecommendations: []vpav1.RecommendedContainerResources{
+			{ContainerName: "app", Target: corev1.ResourceList{"cpu": resource.MustParse("100m")}},
+		},
+	}
+	condition := func(conditionType vpav1.VerticalPodAutoscalerConditionType, status corev1.ConditionStatus, message string) vpav1.VerticalPodAutoscalerCondition {
+		return vpav1.VerticalPodAutoscalerCondition{Type: conditionType, Status: status, Message: message}
+	}
+
+	tests := []struct {
+		name        string
+		status      vpav1.VerticalPodAutoscalerStatus
+		wantStatus  string
+		wantMessage string
+	}{
+		{
+			name:       "no status",
+			status:     vpav1.VerticalPodAutoscalerStatus{},
+			wantStatus: StatusNoRecommendationYet,
+		},
+		{
+			name: "fetching history",
+			status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: &vpav1.RecommendedPodResources{},
+				Conditions:     []vpav1.VerticalPodAutoscalerCondition{condition(vpav1.FetchingHistory, corev1.ConditionTrue, "loading history")},
+			},
+			wantStatus:  StatusNoRecommendationYet,
+			wantMessage: "loading history",
+		},
+		{
+			name: "low confidence",
+			status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: recommendation,
+				Conditions: []vpav1.VerticalPodAutoscalerCondition{
+					condition(vpav1.RecommendationProvided, corev1.ConditionTrue, ""),
+					condition(vpav1.LowConfidence, corev1.ConditionTrue, "not enough history"),
+				},
+			},
+			wantStatus:  StatusLowConfidence,
+			wantMessage: "not enough history",
+		},
+		{
+			name: "recommendation provided",
+			status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: recommendation,
+				Conditions: []vpav1.VerticalPodAutoscalerCondition{
+					condition(vpav1.RecommendationProvided, corev1.ConditionTrue, ""),
+					condition(vpav1.LowConfidence, corev1.ConditionFalse, "ignored"),
+				},
+			},
+			wantStatus: StatusRecommendationProvided,
+		},
+		{
+			name: "no pods matched",
+			status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: recommendation,
+				Conditions:     []vpav1.VerticalPodAutoscalerCondition{condition(vpav1.NoPodsMatched, corev1.ConditionTrue, "No pods match this VPA object")},
+			},
+			wantStatus:  string(vpav1.NoPodsMatched),
+			wantMessage: "No pods match this VPA object",
+		},
+		{
+			name: "config unsupported",
+			status: vpav1.VerticalPodAutoscalerStatus{
+				Conditions: []vpav1.VerticalPodAutoscalerCondition{
+					condition(vpav1.NoPodsMatched, corev1.ConditionTrue, "No pods match this VPA object"),
+					condition(vpav1.ConfigUnsupported, corev1.ConditionTrue, "Cannot read targetRef"),
+				},
+			},
+			wantStatus:  string(vpav1.ConfigUnsupported),
+			wantMessage: "Cannot read targetRef",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := vpaStatus(vpav1.VerticalPodAutoscaler{Status: tt.status})
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
+
+func TestSummarizerWithoutRecommendation(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	summarizer := NewSummarizer()
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	testDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "new-deploy", Namespace: "testing"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{"cpu": resource.MustParse("100m"), "ephemeral-storage": resource.MustParse("1Gi")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	created := metav1.NewTime(time.Now().Add(-5 * time.Hour))
+	testVPA := &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "new-deploy",
+			Namespace:         "testing",
+			Labels:            utils.VPALabels,
+			CreationTimestamp: created,
+		},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "new-deploy"},
+		},
+	}
+
+	_, err := kubeClient.Client.AppsV1().Deployments("testing").Create(context.TODO(), testDeployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers("testing").Create(context.TODO(), testVPA, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	dSummary, ok := got.Namespaces["testing"].Deployments["new-deploy"]
+	if assert.True(t, ok, "workloads without a recommendation should be summarized") {
+		assert.Equal(t, StatusNoRecommendationYet, dSummary.Status)
+		assert.Empty(t, dSummary.Containers)
+		// resources that VPA does not recommend are listed before there is a recommendation
+		assert.Equal(t, map[string]Recommendation{
+			"app": {Requests: corev1.ResourceList{"ephemeral-storage": resource.MustParse("1Gi")}, Limits: corev1.ResourceList{}},
+		}, dSummary.Uncovered)
+		assert.Equal(t, "5h", vpaAge(dSummary.VPACreationTimestamp))
+		assert.True(t, created.Equal(&dSummary.VPACreationTimestamp))
+	}
+}
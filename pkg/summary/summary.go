@@ -22,43 +22,23 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/tools/pager"
 	"k8s.io/klog"
 
+	"github.com/fairwindsops/goldilocks/pkg/kube"
 	"github.com/fairwindsops/goldilocks/pkg/utils"
 )
 
 const (
 	namespaceAllNamespaces = ""
-)
-
-// Summary is for storing a summary of recommendation data by namespace/deployment/container
-type Summary struct {
-	Namespaces map[string]namespaceSummary
-}
 
-type namespaceSummary struct {
-	Namespace   string                       `json:"namespace"`
-	Deployments map[string]deploymentSummary `json:"deployments"`
-}
-
-type deploymentSummary struct {
-	DeploymentName string                      `json:"deploymentName"`
-	Containers     map[string]containerSummary `json:"containers"`
-}
-
-type containerSummary struct {
-	ContainerName string `json:"containerName"`
-
-	// recommendations
-	LowerBound     corev1.ResourceList `json:"lowerBound"`
-	UpperBound     corev1.ResourceList `json:"upperBound"`
-	Target         corev1.ResourceList `json:"target"`
-	UncappedTarget corev1.ResourceList `json:"uncappedTarget"`
-	Limits         corev1.ResourceList `json:"limits"`
-	Requests       corev1.ResourceList `json:"requests"`
-}
+	// defaultPageSize is the number of VPAs and Deployments listed at a time
+	defaultPageSize = 500
+)
 
 // Summarizer represents a source of generating a summary of VPAs
 type Summarizer struct {
@@ -67,8 +47,8 @@ type Summarizer struct {
 	// cached list of vpas
 	vpas []vpav1.VerticalPodAutoscaler
 
-	// cached map of deploy/vpa name -> deployment
-	deploymentForVPANamed map[string]*appsv1.Deployment
+	// cached map of deployment namespace/name -> deployment
+	deploymentForTargetRef map[types.NamespacedName]*appsv1.Deployment
 }
 
 // NewSummarizer returns a Summarizer for all goldilocks managed VPAs in all Namespaces
@@ -78,6 +58,12 @@ func NewSummarizer(setters ...Option) *Summarizer {
 		setter(opts)
 	}
 
+	// clients for the files are created when the summarizer is updated, so that errors reading them can be returned
+	if len(opts.files) <= 0 {
+		opts.kubeClient = kube.GetInstance()
+		opts.vpaClient = kube.GetVPAInstance()
+	}
+
 	return &Summarizer{
 		options: *opts,
 	}
@@ -97,20 +83,23 @@ func NewSummarizerForVPAs(vpas []vpav1.VerticalPodAutoscaler, setters ...Option)
 func (s Summarizer) GetSummary() (Summary, error) {
 	// blank summary
 	summary := Summary{
-		Namespaces: map[string]namespaceSummary{},
+		APIVersion: APIVersion,
+		Namespaces: map[string]NamespaceSummary{},
+		Delta:      corev1.ResourceList{},
 	}
 
 	// if the summarizer is filtering for a single namespace,
 	// then add that namespace by default to the blank summary
 	if s.namespace != namespaceAllNamespaces {
-		summary.Namespaces[s.namespace] = namespaceSummary{
+		summary.Namespaces[s.namespace] = NamespaceSummary{
 			Namespace:   s.namespace,
-			Deployments: map[string]deploymentSummary{},
+			Deployments: map[string]DeploymentSummary{},
+			Delta:       corev1.ResourceList{},
 		}
 	}
 
 	// cached vpas and deployments
-	if s.vpas == nil || s.deploymentForVPANamed == nil {
+	if s.vpas == nil || s.deploymentForTargetRef == nil {
 		err := s.Update()
 		if err != nil {
 			return summary, err
@@ -122,61 +111,98 @@ func (s Summarizer) GetSummary() (Summary, error) {
 		return summary, nil
 	}
 
+	// namespaces looked up for their recommendation policy annotations, and their LimitRanges and ResourceQuotas
+	namespaces := map[string]*corev1.Namespace{}
+	constraints := map[string]*namespaceConstraints{}
+
+	// efficiency scores of the deployments, rolled up to each namespace and the cluster
+	efficiency := s.efficiency
+	if efficiency == nil {
+		efficiency = DefaultEfficiencyPolicy()
+	}
+	nsEfficiency := map[string]*efficiencyTotal{}
+	clusterEfficiency := &efficiencyTotal{}
+
 	for _, vpa := range s.vpas {
 		klog.V(8).Infof("Analyzing vpa: %v", vpa.Name)
 
 		// get or create the namespaceSummary for this VPA's namespace
 		namespace := vpa.Namespace
-		var nsSummary namespaceSummary
+		var nsSummary NamespaceSummary
 		if val, ok := summary.Namespaces[namespace]; ok {
 			nsSummary = val
 		} else {
-			nsSummary = namespaceSummary{
+			nsSummary = NamespaceSummary{
 				Namespace:   namespace,
-				Deployments: map[string]deploymentSummary{},
+				Deployments: map[string]DeploymentSummary{},
+				Delta:       corev1.ResourceList{},
 			}
 			summary.Namespaces[namespace] = nsSummary
 		}
 
-		// VPA.Name := Deployment.Name, as that's how goldilocks works
-		dSummary := deploymentSummary{
-			DeploymentName: vpa.Name,
-			Containers:     map[string]containerSummary{},
+		targetRef := vpaTargetRef(vpa)
+		dSummary := DeploymentSummary{
+			DeploymentName: targetRef.Name,
+			Containers:     map[string]ContainerSummary{},
+			Delta:          corev1.ResourceList{},
 		}
 
-		deployment, ok := s.deploymentForVPANamed[vpa.Name]
+		deployment, ok := s.deploymentForTargetRef[targetRef]
 		if !ok {
-			klog.Errorf("no matching Deployment found for VPA/%s", vpa.Name)
+			klog.Errorf("no matching Deployment found for VPA/%s in Namespace/%s", vpa.Name, vpa.Namespace)
 			continue
 		}
+		dSummary.HelmRelease = helmRelease(deployment)
+		dSummary.Replicas = deploymentReplicas(deployment)
+		dSummary.AvailableReplicas = deployment.Status.AvailableReplicas
+
+		dSummary.Status, dSummary.StatusMessage = vpaStatus(vpa)
+		dSummary.VPACreationTimestamp = vpa.CreationTimestamp
 
+		// workloads without recommendations are still summarized, with their status and no containers
+		var containerRecommendations []vpav1.RecommendedContainerResources
 		if vpa.Status.Recommendation == nil {
 			klog.V(2).Infof("Empty status on %v", dSummary.DeploymentName)
-			continue
-		}
-		if len(vpa.Status.Recommendation.ContainerRecommendations) <= 0 {
+		} else if len(vpa.Status.Recommendation.ContainerRecommendations) <= 0 {
 			klog.V(2).Infof("No recommendations found in the %v vpa.", dSummary.DeploymentName)
-			continue
+		} else {
+			containerRecommendations = vpa.Status.Recommendation.ContainerRecommendations
 		}
 
+		policy := s.policyFor(s.namespaceNamed(namespaces, namespace), deployment)
+		nsConstraints := s.constraintsFor(constraints, namespace)
+
 		// get the full set of excluded containers for this Deployment
 		excludedContainers := sets.NewString().Union(s.excludedContainers)
 		if val, exists := deployment.GetAnnotations()[utils.DeploymentExcludeContainersAnnotation]; exists {
 			excludedContainers.Insert(strings.Split(val, ",")...)
 		}
 
+		// resources that VPA does not recommend are taken from the deployment, so that containers without a recommendation have them too
+		for _, c := range deployment.Spec.Template.Spec.Containers {
+			if excludedContainers.Has(c.Name) {
+				continue
+			}
+			if uncovered := uncoveredResources(c.Resources.Requests, c.Resources.Limits); uncovered != nil {
+				if dSummary.Uncovered == nil {
+					dSummary.Uncovered = map[string]Recommendation{}
+				}
+				dSummary.Uncovered[c.Name] = *uncovered
+			}
+		}
+
 	CONTAINER_REC_LOOP:
-		for _, containerRecommendation := range vpa.Status.Recommendation.ContainerRecommendations {
+		for _, containerRecommendation := range containerRecommendations {
 			if excludedContainers.Has(containerRecommendation.ContainerName) {
 				klog.V(2).Infof("Excluding container Deployment/%s/%s", dSummary.DeploymentName, containerRecommendation.ContainerName)
 				continue CONTAINER_REC_LOOP
 			}
 
-			var cSummary containerSummary
+			var cSummary ContainerSummary
 			for _, c := range deployment.Spec.Template.Spec.Containers {
 				// find the matching container on the deployment
 				if c.Name == containerRecommendation.ContainerName {
-					cSummary = containerSummary{
+					cSummary = ContainerSummary{
 						ContainerName:  containerRecommendation.ContainerName,
 						UpperBound:     utils.FormatResourceList(containerRecommendation.UpperBound),
 						LowerBound:     utils.FormatResourceList(containerRecommendation.LowerBound),
@@ -185,6 +211,18 @@ func (s Summarizer) GetSummary() (Summary, error) {
 						Limits:         utils.FormatResourceList(c.Resources.Limits),
 						Requests:       utils.FormatResourceList(c.Resources.Requests),
 					}
+					cSummary.Guaranteed = guaranteedRecommendation(cSummary.Target).rounded(s.rounding)
+					cSummary.Burstable = burstableRecommendation(cSummary.LowerBound, cSummary.UpperBound).rounded(s.rounding)
+					if policy != nil {
+						policyRecommendation := policy.recommend(cSummary.Target, cSummary.LowerBound, cSummary.UpperBound).rounded(s.rounding)
+						cSummary.Policy = &policyRecommendation
+					}
+					cSummary.clamp(nsConstraints)
+					if uncovered, ok := dSummary.Uncovered[c.Name]; ok {
+						cSummary.Uncovered = &uncovered
+					}
+					cSummary.Delta = resourceDelta(cSummary.Requests, cSummary.Target)
+					addResourceList(dSummary.Delta, cSummary.Delta, int64(dSummary.Replicas))
 					klog.V(6).Infof("Resources for Deployment/%s/%s: Requests: %v Limits: %v", dSummary.DeploymentName, c.Name, cSummary.Requests, cSummary.Limits)
 					dSummary.Containers[cSummary.ContainerName] = cSummary
 					continue CONTAINER_REC_LOOP
@@ -192,17 +230,52 @@ func (s Summarizer) GetSummary() (Summary, error) {
 			}
 		}
 
+		dSummary.Footprint = deploymentFootprint(dSummary)
+
+		// roll the deployment totals up to the namespace and cluster
+		addResourceList(nsSummary.Delta, dSummary.Delta, 1)
+		addResourceList(summary.Delta, dSummary.Delta, 1)
+		if s.pricing != nil {
+			dSummary.Cost = s.pricing.deploymentCost(deployment, dSummary)
+			nsSummary.Cost = addCost(nsSummary.Cost, dSummary.Cost)
+			summary.Cost = addCost(summary.Cost, dSummary.Cost)
+		}
+		if score, ok := efficiency.deploymentScore(dSummary); ok {
+			dSummary.Efficiency = efficiency.efficiency(score)
+			if nsEfficiency[namespace] == nil {
+				nsEfficiency[namespace] = &efficiencyTotal{}
+			}
+			nsEfficiency[namespace].add(score, dSummary.Replicas)
+			clusterEfficiency.add(score, dSummary.Replicas)
+		}
+
 		// update summary maps
 		nsSummary.Deployments[dSummary.DeploymentName] = dSummary
 		summary.Namespaces[nsSummary.Namespace] = nsSummary
 	}
 
+	// quota headroom and efficiency depend on all of the deployments in the namespace
+	for name, nsSummary := range summary.Namespaces {
+		nsSummary.Quota, nsSummary.Warnings = s.constraintsFor(constraints, name).quotaHeadroom(nsSummary)
+		if total, ok := nsEfficiency[name]; ok {
+			nsSummary.Efficiency = total.efficiency(*efficiency)
+		}
+		summary.Namespaces[name] = nsSummary
+	}
+	summary.Efficiency = clusterEfficiency.efficiency(*efficiency)
+
 	return summary, nil
 }
 
 // Update the set of VPAs and Deployments that the Summarizer uses for creating a summary
 func (s *Summarizer) Update() error {
-	err := s.updateVPAs()
+	err := s.loadFiles()
+	if err != nil {
+		klog.Error(err.Error())
+		return err
+	}
+
+	err = s.updateVPAs()
 	if err != nil {
 		klog.Error(err.Error())
 		return err
@@ -217,6 +290,21 @@ func (s *Summarizer) Update() error {
 	return nil
 }
 
+// loadFiles creates clients for the objects in the Summarizer's files, once
+func (s *Summarizer) loadFiles() error {
+	if len(s.files) <= 0 || s.kubeClient != nil {
+		return nil
+	}
+	klog.V(3).Infof("Reading objects from files: %v", s.files)
+	kubeClient, vpaClient, err := kube.GetFileClients(s.files)
+	if err != nil {
+		return err
+	}
+	s.kubeClient = kubeClient
+	s.vpaClient = vpaClient
+	return nil
+}
+
 func (s *Summarizer) updateVPAs() error {
 	nsLog := s.namespace
 	if s.namespace == namespaceAllNamespaces {
@@ -233,13 +321,43 @@ func (s *Summarizer) updateVPAs() error {
 	return nil
 }
 
+// listVPAs lists the VPAs from the cache if there is one, and otherwise lists them from the API server a page at a time
 func (s Summarizer) listVPAs(listOptions metav1.ListOptions) ([]vpav1.VerticalPodAutoscaler, error) {
-	vpas, err := s.vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers(s.namespace).List(context.TODO(), listOptions)
+	vpas := []vpav1.VerticalPodAutoscaler{}
+	if s.cache != nil {
+		selector, err := labels.Parse(listOptions.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		var cached []*vpav1.VerticalPodAutoscaler
+		if s.namespace == namespaceAllNamespaces {
+			cached, err = s.cache.vpas.List(selector)
+		} else {
+			cached, err = s.cache.vpas.VerticalPodAutoscalers(s.namespace).List(selector)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// copied, as summarizing changes the units of some quantities
+		for _, vpa := range cached {
+			vpas = append(vpas, *vpa.DeepCopy())
+		}
+		return vpas, nil
+	}
+
+	listPager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return s.vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers(s.namespace).List(context.TODO(), opts)
+	}))
+	listPager.PageSize = s.pageSize
+	err := listPager.EachListItem(context.TODO(), listOptions, func(obj runtime.Object) error {
+		vpas = append(vpas, *obj.(*vpav1.VerticalPodAutoscaler))
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return vpas.Items, nil
+	return vpas, nil
 }
 
 func getVPAListOptionsForLabels(vpaLabels map[string]string) metav1.ListOptions {
@@ -260,21 +378,101 @@ func (s *Summarizer) updateDeployments() error {
 	}
 	klog.V(10).Infof("Found deployments: %v", deployments)
 
-	// map the deployment.name -> &deployment for easy vpa lookup (since vpa.Name == deployment.Name for matching vpas/deployments)
-	s.deploymentForVPANamed = map[string]*appsv1.Deployment{}
+	// map the deployment namespace/name -> &deployment for easy vpa lookup by targetRef,
+	// as deployments in different namespaces can share a name
+	s.deploymentForTargetRef = map[types.NamespacedName]*appsv1.Deployment{}
 	for _, d := range deployments {
 		d := d
-		s.deploymentForVPANamed[d.Name] = &d
+		s.deploymentForTargetRef[types.NamespacedName{Namespace: d.Namespace, Name: d.Name}] = &d
 	}
 
 	return nil
 }
 
+// vpaTargetRef returns the namespace/name of the Deployment targeted by the VPA.
+// VPAs without a targetRef fall back to the VPA name, as goldilocks names its VPAs after their Deployment.
+func vpaTargetRef(vpa vpav1.VerticalPodAutoscaler) types.NamespacedName {
+	name := vpa.Name
+	if vpa.Spec.TargetRef != nil && vpa.Spec.TargetRef.Name != "" {
+		name = vpa.Spec.TargetRef.Name
+	}
+	return types.NamespacedName{Namespace: vpa.Namespace, Name: name}
+}
+
+// listDeployments lists the Deployments from the cache if there is one, and otherwise lists them from the API server a page at a time
 func (s Summarizer) listDeployments(listOptions metav1.ListOptions) ([]appsv1.Deployment, error) {
-	deployments, err := s.kubeClient.Client.AppsV1().Deployments(s.namespace).List(context.TODO(), listOptions)
+	deployments := []appsv1.Deployment{}
+	if s.cache != nil {
+		selector, err := labels.Parse(listOptions.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		var cached []*appsv1.Deployment
+		if s.namespace == namespaceAllNamespaces {
+			cached, err = s.cache.deployments.List(selector)
+		} else {
+			cached, err = s.cache.deployments.Deployments(s.namespace).List(selector)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// copied, as summarizing changes the units of some quantities
+		for _, d := range cached {
+			deployments = append(deployments, *d.DeepCopy())
+		}
+		return deployments, nil
+	}
+
+	listPager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return s.kubeClient.Client.AppsV1().Deployments(s.namespace).List(context.TODO(), opts)
+	}))
+	listPager.PageSize = s.pageSize
+	err := listPager.EachListItem(context.TODO(), listOptions, func(obj runtime.Object) error {
+		deployments = append(deployments, *obj.(*appsv1.Deployment))
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return deployments.Items, nil
+	return deployments, nil
+}
+
+// namespaceNamed returns the named Namespace, looking it up once and caching it in the map.
+// It returns nil if the Namespace cannot be found, which leaves it without any annotations.
+func (s Summarizer) namespaceNamed(namespaces map[string]*corev1.Namespace, name string) *corev1.Namespace {
+	if ns, ok := namespaces[name]; ok {
+		return ns
+	}
+	var ns *corev1.Namespace
+	var err error
+	if s.cache != nil {
+		ns, err = s.cache.namespaces.Get(name)
+	} else {
+		ns, err = s.kubeClient.Client.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	}
+	if err != nil {
+		klog.V(2).Infof("Error getting Namespace/%s: %v", name, err)
+		ns = nil
+	}
+	namespaces[name] = ns
+	return ns
+}
+
+// policyFor returns the recommendation policy of the Deployment, which is the Summarizer's policy overridden by the
+// annotations on the Namespace and then on the Deployment. It is nil when none of them have a policy.
+func (s Summarizer) policyFor(namespace *corev1.Namespace, deployment *appsv1.Deployment) *RecommendationPolicy {
+	policy := s.policy.merge(nil)
+	if namespace != nil {
+		nsPolicy, err := annotationPolicy(namespace.GetAnnotations())
+		if err != nil {
+			klog.Errorf("Ignoring invalid recommendation policy annotation on Namespace/%s: %v", namespace.Name, err)
+		}
+		policy = policy.merge(nsPolicy)
+	}
+	deploymentPolicy, err := annotationPolicy(deployment.GetAnnotations())
+	if err != nil {
+		klog.Errorf("Ignoring invalid recommendation policy annotation on Deployment/%s in Namespace/%s: %v", deployment.Name, deployment.Namespace, err)
+	}
+	return policy.merge(deploymentPolicy)
 }
@@ -16,12 +16,19 @@ package summary
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/fairwindsops/goldilocks/pkg/kube"
 	"github.com/fairwindsops/goldilocks/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 )
@@ -76,12 +83,15 @@ func TestSummarizer(t *testing.T) {
 	assert.NoError(t, errOk2)
 
 	var summary = Summary{
-		Namespaces: map[string]namespaceSummary{
-			"testing": namespaceSummary{
+		APIVersion: APIVersion,
+		Namespaces: map[string]NamespaceSummary{
+			"testing": NamespaceSummary{
 				Namespace:   "testing",
-				Deployments: map[string]deploymentSummary{},
+				Deployments: map[string]DeploymentSummary{},
+				Delta:       corev1.ResourceList{},
 			},
 		},
+		Delta: corev1.ResourceList{},
 	}
 
 	got, err := summarizer.GetSummary()
@@ -89,3 +99,386 @@ func TestSummarizer(t *testing.T) {
 
 	assert.EqualValues(t, summary, got)
 }
+
+func TestSummarizerQoSRecommendations(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	summarizer := NewSummarizer()
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	var testDeployment = &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-deploy",
+			Namespace: "testing",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+					},
+				},
+			},
+		},
+	}
+	var testVPA = &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-deploy",
+			Labels:    utils.VPALabels,
+			Namespace: "testing",
+		},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "test-deploy",
+			},
+		},
+		Status: vpav1.VerticalPodAutoscalerStatus{
+			Recommendation: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "app",
+						Target:        corev1.ResourceList{"cpu": resource.MustParse("25m"), "memory": resource.MustParse("128Mi")},
+						LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("10m"), "memory": resource.MustParse("64Mi")},
+						UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := kubeClient.Client.AppsV1().Deployments("testing").Create(context.TODO(), testDeployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers("testing").Create(context.TODO(), testVPA, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	cSummary := got.Namespaces["testing"].Deployments["test-deploy"].Containers["app"]
+	guaranteed, err := json.Marshal(cSummary.Guaranteed)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"25m","memory":"128Mi"},"limits":{"cpu":"25m","memory":"128Mi"}}`, string(guaranteed))
+	burstable, err := json.Marshal(cSummary.Burstable)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"10m","memory":"64Mi"},"limits":{"cpu":"1","memory":"1Gi"}}`, string(burstable))
+
+	// suggested values are rounded, while the VPA recommendation is left as it is
+	rounding, err := utils.ParseRoundingPolicy("20m", "100M", utils.RoundNearest)
+	assert.NoError(t, err)
+	roundingSummarizer := NewSummarizer(WithRounding(rounding))
+	roundingSummarizer.kubeClient = kubeClient
+	roundingSummarizer.vpaClient = kubeClientVPA
+
+	got, err = roundingSummarizer.GetSummary()
+	assert.NoError(t, err)
+
+	cSummary = got.Namespaces["testing"].Deployments["test-deploy"].Containers["app"]
+	guaranteed, err = json.Marshal(cSummary.Guaranteed)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"20m","memory":"100M"},"limits":{"cpu":"20m","memory":"100M"}}`, string(guaranteed))
+	burstable, err = json.Marshal(cSummary.Burstable)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"cpu":"20m","memory":"100M"},"limits":{"cpu":"1","memory":"1100M"}}`, string(burstable))
+	target, err := json.Marshal(cSummary.Target)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"cpu":"25m","memory":"128Mi"}`, string(target))
+}
+
+func TestSummarizerDeltas(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	summarizer := NewSummarizer()
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	replicas := int32(3)
+	testDeployments := []*appsv1.Deployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "testing"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "app",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("1Gi")},
+								},
+							},
+						},
+					},
+				},
+			},
+			Status: appsv1.DeploymentStatus{AvailableReplicas: 2},
+		},
+		{
+			// replicas default to one, and memory has no request to compare
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "other"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "app",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{"cpu": resource.MustParse("50m")},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, d := range testDeployments {
+		_, err := kubeClient.Client.AppsV1().Deployments(d.Namespace).Create(context.TODO(), d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		vpa := &vpav1.VerticalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: d.Name, Namespace: d.Namespace, Labels: utils.VPALabels},
+			Spec: vpav1.VerticalPodAutoscalerSpec{
+				TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: d.Name},
+			},
+			Status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: &vpav1.RecommendedPodResources{
+					ContainerRecommendations: []vpav1.RecommendedContainerResources{
+						{
+							ContainerName: "app",
+							Target:        corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("512Mi")},
+						},
+					},
+				},
+			},
+		}
+		_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers(d.Namespace).Create(context.TODO(), vpa, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		delta corev1.ResourceList
+		want  string
+	}{
+		{"container", got.Namespaces["testing"].Deployments["web"].Containers["app"].Delta, `{"cpu":"400m","memory":"512Mi"}`},
+		{"deployment", got.Namespaces["testing"].Deployments["web"].Delta, `{"cpu":"1200m","memory":"1536Mi"}`},
+		{"under-provisioned deployment", got.Namespaces["other"].Deployments["worker"].Delta, `{"cpu":"-50m"}`},
+		{"namespace", got.Namespaces["testing"].Delta, `{"cpu":"1200m","memory":"1536Mi"}`},
+		{"cluster", got.Delta, `{"cpu":"1150m","memory":"1536Mi"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, err := json.Marshal(tt.delta)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(delta))
+		})
+	}
+	web := got.Namespaces["testing"].Deployments["web"]
+	assert.EqualValues(t, 3, web.Replicas)
+	assert.EqualValues(t, 2, web.AvailableReplicas)
+	footprint, err := json.Marshal(web.Footprint)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"podRequests": {"cpu": "500m", "memory": "1Gi"},
+		"podTarget": {"cpu": "100m", "memory": "512Mi"},
+		"totalRequests": {"cpu": "1500m", "memory": "3Gi"},
+		"totalTarget": {"cpu": "300m", "memory": "1536Mi"}
+	}`, string(footprint))
+	assert.Equal(t, "1.15 cores / 1.5 GiB over-provisioned", DeltaString(got.Delta))
+
+	// the memory target of the worker is left out of its footprint, as it has no memory request
+	footprint, err = json.Marshal(got.Namespaces["other"].Deployments["worker"].Footprint)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"podRequests": {"cpu": "50m"},
+		"podTarget": {"cpu": "100m"},
+		"totalRequests": {"cpu": "50m"},
+		"totalTarget": {"cpu": "100m"}
+	}`, string(footprint))
+}
+
+func TestDeltaString(t *testing.T) {
+	tests := []struct {
+		delta corev1.ResourceList
+		want  string
+	}{
+		{corev1.ResourceList{}, "0 cores / 0 GiB over-provisioned"},
+		{corev1.ResourceList{"cpu": resource.MustParse("1500m"), "memory": resource.MustParse("2Gi")}, "1.5 cores / 2 GiB over-provisioned"},
+		{corev1.ResourceList{"cpu": resource.MustParse("-250m")}, "0.25 cores / 0 GiB under-provisioned"},
+		{corev1.ResourceList{"cpu": resource.MustParse("-250m"), "memory": resource.MustParse("100Mi")}, "-0.25 cores / 0.1 GiB over-provisioned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeltaString(tt.delta))
+		})
+	}
+}
+
+func TestSummarizerDuplicateDeploymentNames(t *testing.T) {
+	kubeClientVPA := kube.GetMockVPAClient()
+	kubeClient := kube.GetMockClient()
+
+	summarizer := NewSummarizer()
+	summarizer.kubeClient = kubeClient
+	summarizer.vpaClient = kubeClientVPA
+
+	// an api Deployment and VPA in each namespace, with the same container name but different requests and targets
+	requests := map[string]string{
+		"frontend": "100m",
+		"backend":  "2",
+	}
+	targets := map[string]string{
+		"frontend": "50m",
+		"backend":  "1",
+	}
+	for namespace, cpu := range requests {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "app",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{"cpu": resource.MustParse(cpu)},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		_, err := kubeClient.Client.AppsV1().Deployments(namespace).Create(context.TODO(), deployment, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		vpa := &vpav1.VerticalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace, Labels: utils.VPALabels},
+			Spec: vpav1.VerticalPodAutoscalerSpec{
+				TargetRef: &autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "api"},
+			},
+			Status: vpav1.VerticalPodAutoscalerStatus{
+				Recommendation: &vpav1.RecommendedPodResources{
+					ContainerRecommendations: []vpav1.RecommendedContainerResources{
+						{
+							ContainerName: "app",
+							Target:        corev1.ResourceList{"cpu": resource.MustParse(targets[namespace])},
+						},
+					},
+				},
+			},
+		}
+		_, err = kubeClientVPA.Client.AutoscalingV1().VerticalPodAutoscalers(namespace).Create(context.TODO(), vpa, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	// each namespace reports the requests of its own Deployment, not those of the other api Deployment
+	for namespace, cpu := range requests {
+		dSummary, ok := got.Namespaces[namespace].Deployments["api"]
+		if assert.True(t, ok, "missing api Deployment in Namespace/%s", namespace) {
+			assert.Equal(t, "api", dSummary.DeploymentName)
+			assert.Len(t, dSummary.Containers, 1)
+			cSummary := dSummary.Containers["app"]
+			assert.Equal(t, cpu, cSummary.Requests.Cpu().String(), namespace)
+			assert.Equal(t, targets[namespace], cSummary.Target.Cpu().String(), namespace)
+		}
+	}
+}
+
+// testDumpManifests is a namespace with a deployment and its goldilocks VPA, as they would be dumped from a cluster
+const testDumpManifests = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: demo
+  spec:
+    replicas: 2
+    template:
+      spec:
+        containers:
+        - name: app
+          resources:
+            requests:
+              cpu: 100m
+- apiVersion: autoscaling.k8s.io/v1
+  kind: VerticalPodAutoscaler
+  metadata:
+    name: goldilocks-web
+    namespace: demo
+    labels:
+      creator: Fairwinds
+      source: goldilocks
+  spec:
+    targetRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      name: web
+  status:
+    recommendation:
+      containerRecommendations:
+      - containerName: app
+        target:
+          cpu: 25m
+- apiVersion: autoscaling.k8s.io/v1
+  kind: VerticalPodAutoscaler
+  metadata:
+    name: not-goldilocks
+    namespace: demo
+  spec:
+    targetRef:
+      apiVersion: apps/v1
+      kind: Deployment
+      name: web
+`
+
+func TestSummarizerFromFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldilocks-summary")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "dump.yaml"), []byte(testDumpManifests), 0644))
+
+	summarizer := NewSummarizer(FromFiles(dir))
+	got, err := summarizer.GetSummary()
+	assert.NoError(t, err)
+
+	if assert.Contains(t, got.Namespaces, "demo") {
+		web := got.Namespaces["demo"].Deployments["web"]
+		assert.Equal(t, int32(2), web.Replicas)
+		target := web.Containers["app"].Target
+		assert.Equal(t, "25m", target.Cpu().String())
+		assert.Equal(t, "150m", got.Delta.Cpu().String())
+	}
+
+	// errors reading the files are returned from the summary
+	summarizer = NewSummarizer(FromFiles(filepath.Join(dir, "missing.yaml")))
+	_, err = summarizer.GetSummary()
+	assert.Error(t, err)
+}
+
+func TestUncoveredResources(t *testing.T) {
+	got := uncoveredResources(
+		corev1.ResourceList{"cpu": resource.MustParse("100m"), "ephemeral-storage": resource.MustParse("1Gi"), "hugepages-2Mi": resource.MustParse("100Mi")},
+		corev1.ResourceList{"memory": resource.MustParse("1Gi"), "hugepages-2Mi": resource.MustParse("100Mi")},
+	)
+	gotJSON, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"requests":{"ephemeral-storage":"1Gi","hugepages-2Mi":"100Mi"},"limits":{"hugepages-2Mi":"100Mi"}}`, string(gotJSON))
+
+	// containers with only cpu and memory have nothing uncovered
+	assert.Nil(t, uncoveredResources(corev1.ResourceList{"cpu": resource.MustParse("100m")}, nil))
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package summary summarizes the VPA recommendations of goldilocks by namespace, deployment and container.
+//
+// The Summary model is versioned by APIVersion, which is also written to its JSON and YAML as apiVersion.
+// Within a version the model only evolves in backward compatible ways: fields and types are added but never
+// removed, renamed or changed in meaning, and the JSON name of a field never changes. Removing or changing
+// a field requires a new APIVersion.
+package summary
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// APIVersion is the version of the Summary model
+const APIVersion = "goldilocks.fairwinds.com/v1"
+
+// Summary is for storing a summary of recommendation data by namespace/deployment/container
+type Summary struct {
+	// APIVersion is the version of the model the Summary was created with
+	APIVersion string `json:"apiVersion"`
+
+	// Namespaces are the summaries of each namespace, by name. Its JSON name is capitalized as it was before
+	// the model was versioned, like those of the other fields of the Summary.
+	Namespaces map[string]NamespaceSummary `json:"Namespaces"`
+
+	// Delta is the requests minus recommendations across all namespaces
+	Delta corev1.ResourceList `json:"Delta"`
+
+	// Cost is the estimated monthly cost across all namespaces, when pricing is configured
+	Cost *Cost `json:"Cost,omitempty"`
+
+	// Efficiency is the efficiency score of all deployments, weighted by their replicas
+	Efficiency *Efficiency `json:"Efficiency,omitempty"`
+}
+
+// NamespaceSummary is the summary of the deployments with a goldilocks VPA in a namespace
+type NamespaceSummary struct {
+	Namespace string `json:"namespace"`
+
+	// Deployments are the summaries of each deployment in the namespace, by name
+	Deployments map[string]DeploymentSummary `json:"deployments"`
+
+	// Delta is the requests minus recommendations across all deployments in the namespace
+	Delta corev1.ResourceList `json:"delta"`
+
+	// Cost is the estimated monthly cost of all deployments in the namespace, when pricing is configured
+	Cost *Cost `json:"cost,omitempty"`
+
+	// Efficiency is the efficiency score of the deployments in the namespace, weighted by their replicas
+	Efficiency *Efficiency `json:"efficiency,omitempty"`
+
+	// Quota is the headroom of each ResourceQuota in the namespace, before and after applying the recommendations
+	Quota []QuotaHeadroom `json:"quota,omitempty"`
+
+	// Warnings are the ResourceQuotas that applying the recommendations would exceed
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// DeploymentSummary is the summary of a deployment with a goldilocks VPA
+type DeploymentSummary struct {
+	DeploymentName string `json:"deploymentName"`
+
+	// HelmRelease is the name of the Helm release that deployed the deployment, if any
+	HelmRelease string `json:"helmRelease,omitempty"`
+
+	// Replicas and AvailableReplicas are the desired and available replicas of the deployment
+	Replicas          int32 `json:"replicas"`
+	AvailableReplicas int32 `json:"availableReplicas"`
+
+	// Containers are the summaries of each container with a recommendation, by name
+	Containers map[string]ContainerSummary `json:"containers"`
+
+	// Uncovered is the current requests and limits of resources that VPA does not recommend of each container by name,
+	// including the containers without a recommendation
+	Uncovered map[string]Recommendation `json:"uncovered,omitempty"`
+
+	// Status is the recommendation status of the VPA, and StatusMessage the message of the VPA condition it comes from
+	Status        string `json:"status"`
+	StatusMessage string `json:"statusMessage,omitempty"`
+
+	// VPACreationTimestamp is when the VPA was created
+	VPACreationTimestamp metav1.Time `json:"vpaCreationTimestamp"`
+
+	// Footprint is the requests and recommendations of all containers, for a single pod and all replicas
+	Footprint Footprint `json:"footprint"`
+
+	// Delta is the requests minus recommendations across all containers and replicas
+	Delta corev1.ResourceList `json:"delta"`
+
+	// Cost is the estimated monthly cost of all containers and replicas, when pricing is configured
+	Cost *Cost `json:"cost,omitempty"`
+
+	// Efficiency is how well the requests of the containers fit their VPA bounds, when they have recommendations
+	Efficiency *Efficiency `json:"efficiency,omitempty"`
+}
+
+// ContainerSummary is the VPA recommendation for a container, along with its current requests and limits
+type ContainerSummary struct {
+	ContainerName string `json:"containerName"`
+
+	// recommendations of the VPA
+	LowerBound     corev1.ResourceList `json:"lowerBound"`
+	UpperBound     corev1.ResourceList `json:"upperBound"`
+	Target         corev1.ResourceList `json:"target"`
+	UncappedTarget corev1.ResourceList `json:"uncappedTarget"`
+
+	// current limits and requests of the container
+	Limits   corev1.ResourceList `json:"limits"`
+	Requests corev1.ResourceList `json:"requests"`
+
+	// Guaranteed and Burstable are the suggested requests and limits for each QoS class, rounded by the rounding policy
+	Guaranteed Recommendation `json:"guaranteed"`
+	Burstable  Recommendation `json:"burstable"`
+
+	// Policy is the suggested requests and limits of the recommendation policy, when one applies to the container
+	Policy *Recommendation `json:"policy,omitempty"`
+
+	// Uncovered is the current requests and limits of resources that VPA does not recommend, such as ephemeral-storage
+	// and extended resources, when the container has any. They are also included in Requests and Limits.
+	Uncovered *Recommendation `json:"uncovered,omitempty"`
+
+	// Delta is the requests minus the target, for a single replica
+	Delta corev1.ResourceList `json:"delta"`
+
+	// Warnings are the suggested values that were clamped to, or break, the LimitRanges of the namespace
+	Warnings []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Rounding modes of a RoundingPolicy
+const (
+	RoundUp      = "up"
+	RoundNearest = "nearest"
+)
+
+// RoundingPolicy rounds recommended quantities to steps that are easy to read and copy into a manifest.
+// The memory step also sets the units of rounded memory, e.g. a step of 16Mi gives binary units and 100M gives decimal units.
+// A zero step leaves that resource unrounded.
+type RoundingPolicy struct {
+	CPUStep    resource.Quantity
+	MemoryStep resource.Quantity
+	Mode       string
+}
+
+// ParseRoundingPolicy returns a RoundingPolicy for the cpu and memory steps, either of which may be empty, and the mode
+func ParseRoundingPolicy(cpuStep, memoryStep, mode string) (RoundingPolicy, error) {
+	policy := RoundingPolicy{Mode: mode}
+	if mode != RoundUp && mode != RoundNearest {
+		return policy, fmt.Errorf("unsupported rounding mode %q, must be one of: %s, %s", mode, RoundUp, RoundNearest)
+	}
+
+	var err error
+	if cpuStep != "" {
+		if policy.CPUStep, err = resource.ParseQuantity(cpuStep); err != nil {
+			return policy, fmt.Errorf("invalid cpu rounding step %q: %v", cpuStep, err)
+		}
+	}
+	if memoryStep != "" {
+		if policy.MemoryStep, err = resource.ParseQuantity(memoryStep); err != nil {
+			return policy, fmt.Errorf("invalid memory rounding step %q: %v", memoryStep, err)
+		}
+	}
+	if policy.CPUStep.Sign() < 0 || policy.MemoryStep.Sign() < 0 {
+		return policy, fmt.Errorf("rounding steps must not be negative")
+	}
+	return policy, nil
+}
+
+// RoundResourceList returns a copy of the ResourceList with cpu and memory rounded by the policy
+func (p RoundingPolicy) RoundResourceList(rl v1.ResourceList) v1.ResourceList {
+	if rl == nil {
+		return nil
+	}
+	rounded := v1.ResourceList{}
+	for name, quant := range rl {
+		rounded[name] = p.Round(name, quant)
+	}
+	return rounded
+}
+
+// Round rounds the quantity of the named resource to a multiple of the policy's step for it.
+// CPU is rounded in millicores and memory in bytes. A non-zero quantity is never rounded down to zero.
+func (p RoundingPolicy) Round(name v1.ResourceName, quant resource.Quantity) resource.Quantity {
+	switch name {
+	case v1.ResourceCPU:
+		if p.CPUStep.IsZero() {
+			return quant.DeepCopy()
+		}
+		return *resource.NewMilliQuantity(p.roundToStep(quant.MilliValue(), p.CPUStep.MilliValue()), resource.DecimalSI)
+	case v1.ResourceMemory:
+		if p.MemoryStep.IsZero() {
+			return quant.DeepCopy()
+		}
+		return *resource.NewQuantity(p.roundToStep(quant.Value(), p.MemoryStep.Value()), p.MemoryStep.Format)
+	default:
+		return quant.DeepCopy()
+	}
+}
+
+func (p RoundingPolicy) roundToStep(value, step int64) int64 {
+	if step <= 0 {
+		return value
+	}
+	steps := float64(value) / float64(step)
+	if p.Mode == RoundNearest {
+		steps = math.Round(steps)
+	} else {
+		steps = math.Ceil(steps)
+	}
+	if steps == 0 && value > 0 {
+		steps = 1
+	}
+	return int64(steps) * step
+}
@@ -15,6 +15,8 @@
 package utils
 
 import (
+	"math"
+
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -28,6 +30,14 @@ var (
 	VpaUpdateModeKey = LabelBase + "/" + "vpa-update-mode"
 	// DeploymentExcludeContainersAnnotation is the label used to exclude container names from being reported.
 	DeploymentExcludeContainersAnnotation = LabelBase + "/" + "exclude-containers"
+	// RecommendationsAnnotation is the annotation used to write the current recommendations on a workload.
+	RecommendationsAnnotation = LabelBase + "/" + "recommendations"
+	// RecommendationPolicyAnnotation is the annotation used to override the recommendation policy of a namespace or workload.
+	RecommendationPolicyAnnotation = LabelBase + "/" + "recommendation-policy"
+	// ApplyRecommendationsLabel is the label used to opt a namespace in to having recommendations applied by the webhook.
+	ApplyRecommendationsLabel = LabelBase + "/" + "apply-recommendations"
+	// EnforceRecommendationsLabel is the label used to make the webhook reject, instead of warn about, mis-sized Deployments in a namespace.
+	EnforceRecommendationsLabel = LabelBase + "/" + "enforce-recommendations"
 )
 
 // VPALabels is a set of default labels that get placed on every VPA.
@@ -93,3 +103,31 @@ func FormatResourceList(rl v1.ResourceList) v1.ResourceList {
 	}
 	return rl
 }
+
+// CompareRange returns 0 if the existing quantity is within the lower and upper bounds (inclusive),
+// -1 if it is less than the lower bound, and 1 if it is greater than the upper bound
+func CompareRange(existing, lower, upper resource.Quantity) int {
+	if existing.Cmp(lower) < 0 {
+		return -1
+	}
+	if existing.Cmp(upper) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ScaleQuantity multiplies the quantity of the named resource by the factor, rounding up.
+// CPU is rounded to whole millicores, every other resource to whole units.
+func ScaleQuantity(name v1.ResourceName, quant resource.Quantity, factor float64) resource.Quantity {
+	if name == v1.ResourceCPU {
+		return *resource.NewMilliQuantity(scaleUp(quant.MilliValue(), factor), quant.Format)
+	}
+	return *resource.NewQuantity(scaleUp(quant.Value(), factor), quant.Format)
+}
+
+// scaleUp multiplies the value by the factor and rounds up, ignoring floating point error
+// so that e.g. 100 * 1.1 is 110 rather than 111
+func scaleUp(value int64, factor float64) int64 {
+	scaled := math.Round(float64(value)*factor*1e6) / 1e6
+	return int64(math.Ceil(scaled))
+}
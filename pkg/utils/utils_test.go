@@ -121,3 +121,160 @@ var testFormatResourceCases = []struct {
 		expected:     "124M",
 	},
 }
+
+func TestScaleQuantity(t *testing.T) {
+	for _, tc := range testScaleQuantityCases {
+		res := ScaleQuantity(tc.resourceType, resource.MustParse(tc.quantity), tc.factor)
+		assert.Equal(t, tc.expected, res.String(), tc.description)
+	}
+}
+
+var testScaleQuantityCases = []struct {
+	description  string
+	resourceType v1.ResourceName
+	quantity     string
+	factor       float64
+	expected     string
+}{
+	{
+		description:  "cpu to millicores",
+		resourceType: "cpu",
+		quantity:     "1",
+		factor:       1.1,
+		expected:     "1100m",
+	},
+	{
+		description:  "cpu rounds up",
+		resourceType: "cpu",
+		quantity:     "5m",
+		factor:       0.5,
+		expected:     "3m",
+	},
+	{
+		description:  "cpu ignores floating point error",
+		resourceType: "cpu",
+		quantity:     "100m",
+		factor:       1.1,
+		expected:     "110m",
+	},
+	{
+		description:  "memory to whole bytes",
+		resourceType: "memory",
+		quantity:     "1Ki",
+		factor:       1.5,
+		expected:     "1536",
+	},
+	{
+		description:  "memory keeps units when whole",
+		resourceType: "memory",
+		quantity:     "256Mi",
+		factor:       2,
+		expected:     "512Mi",
+	},
+}
+
+func TestRoundingPolicy(t *testing.T) {
+	for _, tc := range testRoundingPolicyCases {
+		policy, err := ParseRoundingPolicy(tc.cpuStep, tc.memoryStep, tc.mode)
+		assert.NoError(t, err, tc.description)
+		res := policy.Round(tc.resourceType, resource.MustParse(tc.quantity))
+		assert.Equal(t, tc.expected, res.String(), tc.description)
+	}
+}
+
+var testRoundingPolicyCases = []struct {
+	description  string
+	cpuStep      string
+	memoryStep   string
+	mode         string
+	resourceType v1.ResourceName
+	quantity     string
+	expected     string
+}{
+	{
+		description:  "cpu rounds up to the step",
+		cpuStep:      "10m",
+		mode:         RoundUp,
+		resourceType: "cpu",
+		quantity:     "587m",
+		expected:     "590m",
+	},
+	{
+		description:  "cpu rounds to the nearest step",
+		cpuStep:      "50m",
+		mode:         RoundNearest,
+		resourceType: "cpu",
+		quantity:     "587m",
+		expected:     "600m",
+	},
+	{
+		description:  "cpu never rounds down to zero",
+		cpuStep:      "50m",
+		mode:         RoundNearest,
+		resourceType: "cpu",
+		quantity:     "3m",
+		expected:     "50m",
+	},
+	{
+		description:  "cpu without a step is unchanged",
+		memoryStep:   "16Mi",
+		mode:         RoundUp,
+		resourceType: "cpu",
+		quantity:     "587m",
+		expected:     "587m",
+	},
+	{
+		description:  "memory rounds up to binary units",
+		memoryStep:   "16Mi",
+		mode:         RoundUp,
+		resourceType: "memory",
+		quantity:     "274845696",
+		expected:     "272Mi",
+	},
+	{
+		description:  "memory rounds to the nearest decimal units",
+		memoryStep:   "100M",
+		mode:         RoundNearest,
+		resourceType: "memory",
+		quantity:     "274845696",
+		expected:     "300M",
+	},
+	{
+		description:  "memory rounds up to whole Gi",
+		memoryStep:   "1Gi",
+		mode:         RoundUp,
+		resourceType: "memory",
+		quantity:     "1100Mi",
+		expected:     "2Gi",
+	},
+	{
+		description:  "other resources are unchanged",
+		cpuStep:      "10m",
+		memoryStep:   "16Mi",
+		mode:         RoundUp,
+		resourceType: "ephemeral-storage",
+		quantity:     "1001",
+		expected:     "1001",
+	},
+}
+
+func TestParseRoundingPolicyErrors(t *testing.T) {
+	_, err := ParseRoundingPolicy("10m", "16Mi", "down")
+	assert.EqualError(t, err, `unsupported rounding mode "down", must be one of: up, nearest`)
+	_, err = ParseRoundingPolicy("ten", "", RoundUp)
+	assert.Error(t, err)
+	_, err = ParseRoundingPolicy("", "16MB", RoundUp)
+	assert.Error(t, err)
+	_, err = ParseRoundingPolicy("-10m", "", RoundUp)
+	assert.EqualError(t, err, "rounding steps must not be negative")
+}
+
+func TestCompareRange(t *testing.T) {
+	lower := *resource.NewMilliQuantity(25, resource.DecimalSI)
+	upper := *resource.NewMilliQuantity(75, resource.DecimalSI)
+
+	assert.Equal(t, -1, CompareRange(*resource.NewMilliQuantity(10, resource.DecimalSI), lower, upper))
+	assert.Equal(t, 0, CompareRange(*resource.NewMilliQuantity(25, resource.DecimalSI), lower, upper))
+	assert.Equal(t, 0, CompareRange(*resource.NewMilliQuantity(75, resource.DecimalSI), lower, upper))
+	assert.Equal(t, 1, CompareRange(*resource.NewMilliQuantity(100, resource.DecimalSI), lower, upper))
+}
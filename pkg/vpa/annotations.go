@@ -0,0 +1,149 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpa
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// containerRecommendation is the recommendation for a single container written to the recommendations annotation
+type containerRecommendation struct {
+	Target     corev1.ResourceList `json:"target"`
+	LowerBound corev1.ResourceList `json:"lowerBound"`
+	UpperBound corev1.ResourceList `json:"upperBound"`
+}
+
+// AnnotateDeployment writes the recommendation of a goldilocks managed VPA as an annotation on its Deployment.
+// The annotation is only written when the recommendation has changed by more than the AnnotationChangeThreshold.
+func (r Reconciler) AnnotateDeployment(vpa *vpav1.VerticalPodAutoscaler) error {
+	if !labels.SelectorFromSet(utils.VPALabels).Matches(labels.Set(vpa.GetLabels())) {
+		klog.V(4).Infof("VPA/%s in Namespace/%s is not managed by goldilocks, not annotating", vpa.Name, vpa.Namespace)
+		return nil
+	}
+	if vpa.Spec.TargetRef == nil || vpa.Spec.TargetRef.Kind != "Deployment" {
+		return nil
+	}
+	if vpa.Status.Recommendation == nil || len(vpa.Status.Recommendation.ContainerRecommendations) <= 0 {
+		klog.V(4).Infof("VPA/%s in Namespace/%s has no recommendation yet, not annotating", vpa.Name, vpa.Namespace)
+		return nil
+	}
+
+	deploymentName := vpa.Spec.TargetRef.Name
+	deployment, err := r.KubeClient.Client.AppsV1().Deployments(vpa.Namespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting Deployment/%s in Namespace/%s: %v", deploymentName, vpa.Namespace, err)
+		return err
+	}
+
+	recommendations := map[string]containerRecommendation{}
+	for _, c := range vpa.Status.Recommendation.ContainerRecommendations {
+		recommendations[c.ContainerName] = containerRecommendation{
+			Target:     c.Target,
+			LowerBound: c.LowerBound,
+			UpperBound: c.UpperBound,
+		}
+	}
+
+	if existing, ok := deployment.GetAnnotations()[utils.RecommendationsAnnotation]; ok {
+		current := map[string]containerRecommendation{}
+		if err := json.Unmarshal([]byte(existing), &current); err != nil {
+			klog.V(2).Infof("Replacing unreadable recommendations annotation on Deployment/%s in Namespace/%s: %v", deploymentName, vpa.Namespace, err)
+		} else if !recommendationsChanged(current, recommendations, r.AnnotationChangeThreshold) {
+			klog.V(5).Infof("Recommendations for Deployment/%s in Namespace/%s have not changed meaningfully, not annotating", deploymentName, vpa.Namespace)
+			return nil
+		}
+	}
+
+	if r.DryRun {
+		klog.Infof("Not annotating Deployment/%s in Namespace/%s due to dryrun.", deploymentName, vpa.Namespace)
+		return nil
+	}
+
+	recommendationsJSON, err := json.Marshal(recommendations)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				utils.RecommendationsAnnotation: string(recommendationsJSON),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.KubeClient.Client.AppsV1().Deployments(vpa.Namespace).Patch(context.TODO(), deploymentName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("Error annotating Deployment/%s in Namespace/%s: %v", deploymentName, vpa.Namespace, err)
+		return err
+	}
+	klog.Infof("Annotated Deployment/%s in Namespace/%s with recommendations", deploymentName, vpa.Namespace)
+	return nil
+}
+
+// recommendationsChanged returns whether any container, bound or resource was added or removed,
+// or any quantity changed by more than the threshold (as a fraction of the larger quantity)
+func recommendationsChanged(current, desired map[string]containerRecommendation, threshold float64) bool {
+	if len(current) != len(desired) {
+		return true
+	}
+	for name, d := range desired {
+		c, ok := current[name]
+		if !ok {
+			return true
+		}
+		if resourceListChanged(c.Target, d.Target, threshold) ||
+			resourceListChanged(c.LowerBound, d.LowerBound, threshold) ||
+			resourceListChanged(c.UpperBound, d.UpperBound, threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceListChanged(current, desired corev1.ResourceList, threshold float64) bool {
+	if len(current) != len(desired) {
+		return true
+	}
+	for name, d := range desired {
+		c, ok := current[name]
+		if !ok {
+			return true
+		}
+		currentValue := float64(c.MilliValue())
+		desiredValue := float64(d.MilliValue())
+		largest := math.Max(math.Abs(currentValue), math.Abs(desiredValue))
+		if largest == 0 {
+			continue
+		}
+		if math.Abs(currentValue-desiredValue)/largest > threshold {
+			return true
+		}
+	}
+	return false
+}
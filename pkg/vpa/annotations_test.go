@@ -0,0 +1,146 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vpa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func testRecommendedVPA(cpu string) *vpav1.VerticalPodAutoscaler {
+	return &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testDeployment.Name,
+			Namespace: nsTesting.Name,
+			Labels:    utils.VPALabels,
+		},
+		Spec: vpav1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscaling.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       testDeployment.Name,
+			},
+		},
+		Status: vpav1.VerticalPodAutoscalerStatus{
+			Recommendation: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "container",
+						Target:        corev1.ResourceList{"cpu": resource.MustParse(cpu), "memory": resource.MustParse("128Mi")},
+						LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("10m"), "memory": resource.MustParse("64Mi")},
+						UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("256Mi")},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_AnnotateDeployment(t *testing.T) {
+	setupVPAForTests()
+	rec := GetInstance()
+	rec.AnnotationChangeThreshold = 0.1
+
+	deployments := rec.KubeClient.Client.AppsV1().Deployments(nsTesting.Name)
+	_, err := deployments.Create(context.TODO(), testDeployment, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	getAnnotation := func() string {
+		deployment, err := deployments.Get(context.TODO(), testDeployment.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return deployment.Annotations[utils.RecommendationsAnnotation]
+	}
+
+	// first recommendation is written
+	assert.NoError(t, rec.AnnotateDeployment(testRecommendedVPA("100m")))
+	first := getAnnotation()
+	assert.JSONEq(t, `{"container":{"target":{"cpu":"100m","memory":"128Mi"},"lowerBound":{"cpu":"10m","memory":"64Mi"},"upperBound":{"cpu":"1","memory":"256Mi"}}}`, first)
+
+	// a change under the threshold is skipped
+	assert.NoError(t, rec.AnnotateDeployment(testRecommendedVPA("105m")))
+	assert.Equal(t, first, getAnnotation())
+
+	// a change over the threshold is written
+	assert.NoError(t, rec.AnnotateDeployment(testRecommendedVPA("200m")))
+	assert.Contains(t, getAnnotation(), `"target":{"cpu":"200m"`)
+
+	// VPAs that are not managed by goldilocks are ignored
+	foreign := testRecommendedVPA("500m")
+	foreign.Labels = nil
+	assert.NoError(t, rec.AnnotateDeployment(foreign))
+	assert.Contains(t, getAnnotation(), `"target":{"cpu":"200m"`)
+}
+
+func Test_recommendationsChanged(t *testing.T) {
+	current := map[string]containerRecommendation{
+		"container": {
+			Target: corev1.ResourceList{"cpu": resource.MustParse("100m")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]containerRecommendation
+		want    bool
+	}{
+		{
+			name:    "unchanged",
+			desired: current,
+			want:    false,
+		},
+		{
+			name: "within threshold",
+			desired: map[string]containerRecommendation{
+				"container": {Target: corev1.ResourceList{"cpu": resource.MustParse("91m")}},
+			},
+			want: false,
+		},
+		{
+			name: "over threshold",
+			desired: map[string]containerRecommendation{
+				"container": {Target: corev1.ResourceList{"cpu": resource.MustParse("89m")}},
+			},
+			want: true,
+		},
+		{
+			name: "new resource",
+			desired: map[string]containerRecommendation{
+				"container": {Target: corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("1Mi")}},
+			},
+			want: true,
+		},
+		{
+			name: "new container",
+			desired: map[string]containerRecommendation{
+				"other": {Target: corev1.ResourceList{"cpu": resource.MustParse("100m")}},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recommendationsChanged(current, tt.desired, 0.1))
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/client-go/util/retry"
@@ -44,6 +45,13 @@ type Reconciler struct {
 	DryRun            bool
 	IncludeNamespaces []string
 	ExcludeNamespaces []string
+
+	// AnnotateWorkloads enables writing recommendations as annotations on the workloads
+	AnnotateWorkloads bool
+	// AnnotationDebounce is how long a VPA must go without changes before its workload is annotated
+	AnnotationDebounce time.Duration
+	// AnnotationChangeThreshold is the fraction a recommendation must change by before the annotation is rewritten
+	AnnotationChangeThreshold float64
 }
 
 var singleton *Reconciler
@@ -78,7 +86,7 @@ func (r Reconciler) ReconcileNamespace(namespace *corev1.Namespace) error {
 		return err
 	}
 
-	if !r.namespaceIsManaged(namespace) {
+	if !r.NamespaceIsManaged(namespace) {
 		klog.V(2).Infof("Namespace/%s is not managed, cleaning up VPAs...", namespace.Name)
 		// Namespaced used to be managed, but isn't anymore. Delete all of the
 		// VPAs that we control.
@@ -123,7 +131,9 @@ func (r Reconciler) checkDeploymentLabels(deployment *appsv1.Deployment) (bool,
 	return false, nil
 }
 
-func (r Reconciler) namespaceIsManaged(namespace *corev1.Namespace) bool {
+// NamespaceIsManaged returns whether the Reconciler creates VPAs for the Deployments in the namespace, from its
+// enabled label, then the included and excluded namespaces, then whether the Reconciler is on by default
+func (r Reconciler) NamespaceIsManaged(namespace *corev1.Namespace) bool {
 	for k, v := range namespace.ObjectMeta.Labels {
 		klog.V(4).Infof("Namespace/%s found label: %s=%s", namespace.Name, k, v)
 		if strings.ToLower(k) != utils.VpaEnabledLabel {
@@ -271,7 +271,7 @@ func Test_listVPA(t *testing.T) {
 	assert.Empty(t, vpaList3)
 }
 
-func Test_namespaceIsManaged(t *testing.T) {
+func Test_NamespaceIsManaged(t *testing.T) {
 	tests := []struct {
 		name      string
 		namespace *corev1.Namespace
@@ -295,7 +295,7 @@ func Test_namespaceIsManaged(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := GetInstance().namespaceIsManaged(tt.namespace)
+			got := GetInstance().NamespaceIsManaged(tt.namespace)
 			assert.Equal(t, got, tt.want)
 		})
 	}
@@ -308,44 +308,44 @@ func Test_checkNamespaceLists(t *testing.T) {
 	vpaReconciler.OnByDefault = false
 	vpaReconciler.IncludeNamespaces = []string{}
 	vpaReconciler.ExcludeNamespaces = []string{}
-	got := vpaReconciler.namespaceIsManaged(nsNotLabeled)
+	got := vpaReconciler.NamespaceIsManaged(nsNotLabeled)
 	assert.Equal(t, false, got)
 
 	vpaReconciler.OnByDefault = true
 	vpaReconciler.IncludeNamespaces = []string{}
 	vpaReconciler.ExcludeNamespaces = []string{}
-	got = vpaReconciler.namespaceIsManaged(nsNotLabeled)
+	got = vpaReconciler.NamespaceIsManaged(nsNotLabeled)
 	assert.Equal(t, true, got)
 
 	vpaReconciler.OnByDefault = false
 	vpaReconciler.IncludeNamespaces = []string{nsNotLabeled.ObjectMeta.Name}
 	vpaReconciler.ExcludeNamespaces = []string{}
-	got = vpaReconciler.namespaceIsManaged(nsNotLabeled)
+	got = vpaReconciler.NamespaceIsManaged(nsNotLabeled)
 	assert.Equal(t, true, got)
 
 	vpaReconciler.OnByDefault = true
 	vpaReconciler.IncludeNamespaces = []string{}
 	vpaReconciler.ExcludeNamespaces = []string{nsNotLabeled.ObjectMeta.Name}
-	got = vpaReconciler.namespaceIsManaged(nsNotLabeled)
+	got = vpaReconciler.NamespaceIsManaged(nsNotLabeled)
 	assert.Equal(t, false, got)
 
 	// Labels take precedence over CLI options
 	vpaReconciler.OnByDefault = true
 	vpaReconciler.IncludeNamespaces = []string{}
 	vpaReconciler.ExcludeNamespaces = []string{}
-	got = vpaReconciler.namespaceIsManaged(nsLabeledFalse)
+	got = vpaReconciler.NamespaceIsManaged(nsLabeledFalse)
 	assert.Equal(t, false, got)
 
 	vpaReconciler.OnByDefault = false
 	vpaReconciler.IncludeNamespaces = []string{nsLabeledFalse.ObjectMeta.Name}
 	vpaReconciler.ExcludeNamespaces = []string{}
-	got = vpaReconciler.namespaceIsManaged(nsLabeledFalse)
+	got = vpaReconciler.NamespaceIsManaged(nsLabeledFalse)
 	assert.Equal(t, false, got)
 
 	vpaReconciler.OnByDefault = false
 	vpaReconciler.IncludeNamespaces = []string{}
 	vpaReconciler.ExcludeNamespaces = []string{nsLabeledTrue.ObjectMeta.Name}
-	got = vpaReconciler.namespaceIsManaged(nsLabeledTrue)
+	got = vpaReconciler.NamespaceIsManaged(nsLabeledTrue)
 	assert.Equal(t, true, got)
 }
 
@@ -0,0 +1,149 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// patchOperation is a single JSONPatch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// mutatePod sets the resources of a new Pod's containers from the goldilocks recommendation for its Deployment.
+// Any failure to find a recommendation admits the Pod unchanged, so the webhook always fails open.
+func (opts options) mutatePod(req *admissionv1.AdmissionRequest) *admissionResponse {
+	if req.Kind.Kind != "Pod" || req.Operation != admissionv1.Create {
+		return allowed()
+	}
+
+	pod := corev1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		klog.Errorf("Error decoding Pod in Namespace/%s: %v", req.Namespace, err)
+		return allowed()
+	}
+	// the namespace is not always set on the object of a create request
+	namespace := req.Namespace
+
+	enabled, err := opts.namespaceHasLabel(namespace, utils.ApplyRecommendationsLabel)
+	if err != nil {
+		klog.Errorf("Error getting Namespace/%s: %v", namespace, err)
+		return allowed()
+	}
+	if !enabled {
+		klog.V(6).Infof("Namespace/%s is not opted in to applying recommendations", namespace)
+		return allowed()
+	}
+
+	deployment, err := opts.deploymentForOwner(namespace, &pod)
+	if err != nil {
+		klog.Errorf("Error finding the Deployment for a Pod in Namespace/%s: %v", namespace, err)
+		return allowed()
+	}
+	if deployment == nil {
+		klog.V(6).Infof("Pod in Namespace/%s is not owned by a Deployment", namespace)
+		return allowed()
+	}
+
+	recommendation, err := opts.recommendationForDeployment(deployment)
+	if err != nil {
+		klog.Errorf("Error getting the recommendation for Deployment/%s in Namespace/%s: %v", deployment.Name, namespace, err)
+		return allowed()
+	}
+	if recommendation == nil {
+		klog.V(4).Infof("No recommendation found for Deployment/%s in Namespace/%s", deployment.Name, namespace)
+		return allowed()
+	}
+
+	patch := opts.podResourcesPatch(&pod, recommendation, opts.excludedContainersFor(deployment, &pod))
+	if len(patch) <= 0 {
+		return allowed()
+	}
+
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		klog.Errorf("Error encoding patch for Deployment/%s in Namespace/%s: %v", deployment.Name, namespace, err)
+		return allowed()
+	}
+
+	klog.V(3).Infof("Applying recommendations to a Pod of Deployment/%s in Namespace/%s", deployment.Name, namespace)
+	patchType := admissionv1.PatchTypeJSONPatch
+	response := allowed()
+	response.Patch = patchJSON
+	response.PatchType = &patchType
+	return response
+}
+
+// podResourcesPatch returns the patch operations setting the resources of each container that has a recommendation
+func (opts options) podResourcesPatch(pod *corev1.Pod, recommendation *vpav1.RecommendedPodResources, excludedContainers sets.String) []patchOperation {
+	patch := []patchOperation{}
+	for i, c := range pod.Spec.Containers {
+		if excludedContainers.Has(c.Name) {
+			klog.V(2).Infof("Excluding container %s", c.Name)
+			continue
+		}
+		containerRecommendation := containerRecommendation(recommendation, c.Name)
+		if containerRecommendation == nil {
+			continue
+		}
+
+		resources := recommendedResources(c.Resources, containerRecommendation.Target, containerRecommendation.UpperBound, opts.setLimits)
+		patch = append(patch, patchOperation{
+			Op:    "add",
+			Path:  fmt.Sprintf("/spec/containers/%d/resources", i),
+			Value: resources,
+		})
+	}
+	return patch
+}
+
+// recommendedResources returns a copy of the current resources with the requests set to the target,
+// and the limits set to the upper bound when setLimits is true. Requests are never set above an existing limit.
+func recommendedResources(current corev1.ResourceRequirements, target, upperBound corev1.ResourceList, setLimits bool) corev1.ResourceRequirements {
+	resources := *current.DeepCopy()
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+
+	if setLimits {
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		for name, quantity := range upperBound {
+			resources.Limits[name] = quantity.DeepCopy()
+		}
+	}
+
+	for name, quantity := range target {
+		request := quantity.DeepCopy()
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			request = limit.DeepCopy()
+		}
+		resources.Requests[name] = request
+	}
+	return resources
+}
@@ -0,0 +1,248 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func setupWebhookForTests(t *testing.T, nsLabels map[string]string) options {
+	opts := options{
+		kubeClient:         kube.GetMockClient(),
+		vpaClient:          kube.GetMockVPAClient(),
+		vpaLabels:          utils.VPALabels,
+		excludedContainers: sets.NewString(),
+		lowerTolerance:     0.5,
+		upperTolerance:     1,
+	}
+
+	isController := true
+	objects := []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "testing", Labels: nsLabels},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: "testing",
+				Annotations: map[string]string{
+					utils.DeploymentExcludeContainersAnnotation: "sidecar",
+				},
+			},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-5d8f",
+				Namespace: "testing",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Deployment", Name: "app", Controller: &isController},
+				},
+			},
+		},
+	}
+	for _, obj := range objects {
+		var err error
+		switch o := obj.(type) {
+		case *corev1.Namespace:
+			_, err = opts.kubeClient.Client.CoreV1().Namespaces().Create(context.TODO(), o, metav1.CreateOptions{})
+		case *appsv1.Deployment:
+			_, err = opts.kubeClient.Client.AppsV1().Deployments("testing").Create(context.TODO(), o, metav1.CreateOptions{})
+		case *appsv1.ReplicaSet:
+			_, err = opts.kubeClient.Client.AppsV1().ReplicaSets("testing").Create(context.TODO(), o, metav1.CreateOptions{})
+		}
+		assert.NoError(t, err)
+	}
+
+	vpa := &vpav1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "testing", Labels: utils.VPALabels},
+		Status: vpav1.VerticalPodAutoscalerStatus{
+			Recommendation: &vpav1.RecommendedPodResources{
+				ContainerRecommendations: []vpav1.RecommendedContainerResources{
+					{
+						ContainerName: "app",
+						Target:        corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("128Mi")},
+						LowerBound:    corev1.ResourceList{"cpu": resource.MustParse("50m"), "memory": resource.MustParse("64Mi")},
+						UpperBound:    corev1.ResourceList{"cpu": resource.MustParse("200m"), "memory": resource.MustParse("256Mi")},
+					},
+					{
+						ContainerName: "sidecar",
+						Target:        corev1.ResourceList{"cpu": resource.MustParse("10m")},
+					},
+				},
+			},
+		},
+	}
+	_, err := opts.vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers("testing").Create(context.TODO(), vpa, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	return opts
+}
+
+func podAdmissionRequest(t *testing.T, pod *corev1.Pod) *admissionv1.AdmissionRequest {
+	raw, err := json.Marshal(pod)
+	assert.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		UID:       "1234",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Namespace: "testing",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func testPod() *corev1.Pod {
+	isController := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "app-5d8f-",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "app-5d8f", Controller: &isController},
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"cpu": resource.MustParse("1")},
+						Limits:   corev1.ResourceList{"memory": resource.MustParse("64Mi")},
+					},
+				},
+				{Name: "sidecar"},
+			},
+		},
+	}
+}
+
+func Test_mutatePod(t *testing.T) {
+	tests := []struct {
+		name      string
+		nsLabels  map[string]string
+		setLimits bool
+		want      []patchOperation
+	}{
+		{
+			name:     "namespace not opted in",
+			nsLabels: map[string]string{},
+			want:     nil,
+		},
+		{
+			name:     "requests only, capped at existing limits",
+			nsLabels: map[string]string{utils.ApplyRecommendationsLabel: "true"},
+			want: []patchOperation{
+				{
+					Op:   "add",
+					Path: "/spec/containers/0/resources",
+					Value: map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "100m", "memory": "64Mi"},
+						"limits":   map[string]interface{}{"memory": "64Mi"},
+					},
+				},
+			},
+		},
+		{
+			name:      "requests and limits",
+			nsLabels:  map[string]string{utils.ApplyRecommendationsLabel: "true"},
+			setLimits: true,
+			want: []patchOperation{
+				{
+					Op:   "add",
+					Path: "/spec/containers/0/resources",
+					Value: map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+						"limits":   map[string]interface{}{"cpu": "200m", "memory": "256Mi"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := setupWebhookForTests(t, tt.nsLabels)
+			opts.setLimits = tt.setLimits
+
+			got := opts.mutatePod(podAdmissionRequest(t, testPod()))
+			assert.True(t, got.Allowed)
+			if tt.want == nil {
+				assert.Nil(t, got.Patch)
+				return
+			}
+
+			var patch []patchOperation
+			assert.NoError(t, json.Unmarshal(got.Patch, &patch))
+			assert.EqualValues(t, tt.want, patch)
+		})
+	}
+}
+
+func Test_mutatePodFailsOpen(t *testing.T) {
+	opts := setupWebhookForTests(t, map[string]string{utils.ApplyRecommendationsLabel: "true"})
+
+	// owned by a ReplicaSet that does not exist
+	pod := testPod()
+	pod.OwnerReferences[0].Name = "missing"
+	got := opts.mutatePod(podAdmissionRequest(t, pod))
+	assert.True(t, got.Allowed)
+	assert.Nil(t, got.Patch)
+
+	// not decodable as a Pod
+	req := podAdmissionRequest(t, testPod())
+	req.Object.Raw = []byte("not a pod")
+	got = opts.mutatePod(req)
+	assert.True(t, got.Allowed)
+	assert.Nil(t, got.Patch)
+}
+
+func Test_serveAdmission(t *testing.T) {
+	opts := setupWebhookForTests(t, map[string]string{})
+
+	review, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  podAdmissionRequest(t, testPod()),
+	})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	serveAdmission(opts.mutatePod).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(review)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	got := admissionv1.AdmissionReview{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "AdmissionReview", got.Kind)
+	assert.EqualValues(t, "1234", got.Response.UID)
+	assert.True(t, got.Response.Allowed)
+
+	w = httptest.NewRecorder()
+	serveAdmission(opts.mutatePod).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
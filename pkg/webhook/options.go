@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"github.com/fairwindsops/goldilocks/pkg/kube"
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type Option func(*options)
+
+// options for looking up recommendations during admission
+type options struct {
+	kubeClient         *kube.ClientInstance
+	vpaClient          *kube.VPAClientInstance
+	vpaLabels          map[string]string
+	excludedContainers sets.String
+	setLimits          bool
+	lowerTolerance     float64
+	upperTolerance     float64
+}
+
+// defaultOptions for the webhook
+func defaultOptions() *options {
+	return &options{
+		kubeClient:         kube.GetInstance(),
+		vpaClient:          kube.GetVPAInstance(),
+		vpaLabels:          utils.VPALabels,
+		excludedContainers: sets.NewString(),
+		lowerTolerance:     0.5,
+		upperTolerance:     1,
+	}
+}
+
+// ExcludeContainers is an Option for never applying recommendations to certain containers
+func ExcludeContainers(excludedContainers sets.String) Option {
+	return func(opts *options) {
+		opts.excludedContainers = excludedContainers
+	}
+}
+
+// ForVPAsWithLabels is an Option for limiting the recommendations to VPAs matching the labels
+func ForVPAsWithLabels(vpaLabels map[string]string) Option {
+	return func(opts *options) {
+		opts.vpaLabels = vpaLabels
+	}
+}
+
+// SetLimits is an Option for also setting container limits to the recommendation upper bound
+func SetLimits(setLimits bool) Option {
+	return func(opts *options) {
+		opts.setLimits = setLimits
+	}
+}
+
+// WithTolerances is an Option for how far requests may fall outside the recommended bounds before being reported,
+// as a fraction of the lower bound (below it) and of the upper bound (above it)
+func WithTolerances(lower, upper float64) Option {
+	return func(opts *options) {
+		opts.lowerTolerance = lower
+		opts.upperTolerance = upper
+	}
+}
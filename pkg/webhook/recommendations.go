@@ -0,0 +1,112 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// namespaceHasLabel returns whether the namespace has the given label set to a true value
+func (opts options) namespaceHasLabel(namespace string, label string) (bool, error) {
+	ns, err := opts.kubeClient.Client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	val, ok := ns.GetLabels()[label]
+	if !ok {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		klog.Errorf("Found unsupported value for Namespace/%s label %s=%s, defaulting to false", namespace, label, val)
+		return false, nil
+	}
+	return enabled, nil
+}
+
+// deploymentForOwner walks the controller owner references of a pod (through its ReplicaSet) to find the owning Deployment.
+// A nil Deployment is returned when the owner is not a Deployment.
+func (opts options) deploymentForOwner(namespace string, owner metav1.Object) (*appsv1.Deployment, error) {
+	rsRef := metav1.GetControllerOf(owner)
+	if rsRef == nil || rsRef.Kind != "ReplicaSet" {
+		return nil, nil
+	}
+	rs, err := opts.kubeClient.Client.AppsV1().ReplicaSets(namespace).Get(context.TODO(), rsRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	deployRef := metav1.GetControllerOf(rs)
+	if deployRef == nil || deployRef.Kind != "Deployment" {
+		return nil, nil
+	}
+	return opts.kubeClient.Client.AppsV1().Deployments(namespace).Get(context.TODO(), deployRef.Name, metav1.GetOptions{})
+}
+
+// recommendationForDeployment returns the recommendation of the goldilocks managed VPA for the Deployment.
+// A nil recommendation is returned when there is no such VPA or it has not recommended anything yet.
+func (opts options) recommendationForDeployment(deployment *appsv1.Deployment) (*vpav1.RecommendedPodResources, error) {
+	// VPA.Name := Deployment.Name, as that's how goldilocks works
+	vpa, err := opts.vpaClient.Client.AutoscalingV1().VerticalPodAutoscalers(deployment.Namespace).Get(context.TODO(), deployment.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !labels.SelectorFromSet(opts.vpaLabels).Matches(labels.Set(vpa.GetLabels())) {
+		klog.V(4).Infof("VPA/%s in Namespace/%s is not managed by goldilocks", vpa.Name, vpa.Namespace)
+		return nil, nil
+	}
+	if vpa.Status.Recommendation == nil || len(vpa.Status.Recommendation.ContainerRecommendations) <= 0 {
+		return nil, nil
+	}
+	return vpa.Status.Recommendation, nil
+}
+
+// excludedContainersFor returns the full set of excluded containers for the Deployment and any additional objects
+func (opts options) excludedContainersFor(objs ...metav1.Object) sets.String {
+	excludedContainers := sets.NewString().Union(opts.excludedContainers)
+	for _, obj := range objs {
+		if val, exists := obj.GetAnnotations()[utils.DeploymentExcludeContainersAnnotation]; exists {
+			excludedContainers.Insert(strings.Split(val, ",")...)
+		}
+	}
+	return excludedContainers
+}
+
+// containerRecommendation returns the recommendation for the named container, or nil if there is none
+func containerRecommendation(recommendation *vpav1.RecommendedPodResources, containerName string) *vpav1.RecommendedContainerResources {
+	for i := range recommendation.ContainerRecommendations {
+		if recommendation.ContainerRecommendations[i].ContainerName == containerName {
+			return &recommendation.ContainerRecommendations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/klog"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+// validatedResources are the container requests compared against the recommendation
+var validatedResources = []corev1.ResourceName{
+	corev1.ResourceCPU,
+	corev1.ResourceMemory,
+}
+
+// validateDeployment warns about Deployments with requests far outside the recommended bounds of their goldilocks VPA,
+// or rejects them in namespaces labeled to enforce recommendations.
+// Any failure to find a recommendation admits the Deployment, so the webhook always fails open.
+func (opts options) validateDeployment(req *admissionv1.AdmissionRequest) *admissionResponse {
+	if req.Kind.Kind != "Deployment" || (req.Operation != admissionv1.Create && req.Operation != admissionv1.Update) {
+		return allowed()
+	}
+
+	deployment := appsv1.Deployment{}
+	if err := json.Unmarshal(req.Object.Raw, &deployment); err != nil {
+		klog.Errorf("Error decoding Deployment in Namespace/%s: %v", req.Namespace, err)
+		return allowed()
+	}
+	// the namespace is not always set on the object of a create request
+	deployment.Namespace = req.Namespace
+
+	recommendation, err := opts.recommendationForDeployment(&deployment)
+	if err != nil {
+		klog.Errorf("Error getting the recommendation for Deployment/%s in Namespace/%s: %v", deployment.Name, deployment.Namespace, err)
+		return allowed()
+	}
+	if recommendation == nil {
+		klog.V(4).Infof("No recommendation found for Deployment/%s in Namespace/%s", deployment.Name, deployment.Namespace)
+		return allowed()
+	}
+
+	warnings := opts.missizedContainers(&deployment, recommendation)
+	if len(warnings) <= 0 {
+		return allowed()
+	}
+
+	enforced, err := opts.namespaceHasLabel(deployment.Namespace, utils.EnforceRecommendationsLabel)
+	if err != nil {
+		klog.Errorf("Error getting Namespace/%s: %v", deployment.Namespace, err)
+	}
+
+	response := allowed()
+	if enforced {
+		klog.V(2).Infof("Rejecting Deployment/%s in Namespace/%s: %s", deployment.Name, deployment.Namespace, strings.Join(warnings, "; "))
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: strings.Join(warnings, "; "),
+		}
+		return response
+	}
+
+	klog.V(3).Infof("Warning about Deployment/%s in Namespace/%s: %s", deployment.Name, deployment.Namespace, strings.Join(warnings, "; "))
+	response.Warnings = warnings
+	return response
+}
+
+// missizedContainers returns a message for each container request outside the tolerated recommended bounds
+func (opts options) missizedContainers(deployment *appsv1.Deployment, recommendation *vpav1.RecommendedPodResources) []string {
+	excludedContainers := opts.excludedContainersFor(deployment)
+
+	messages := []string{}
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if excludedContainers.Has(c.Name) {
+			continue
+		}
+		containerRecommendation := containerRecommendation(recommendation, c.Name)
+		if containerRecommendation == nil {
+			continue
+		}
+
+		for _, name := range validatedResources {
+			request, ok := c.Resources.Requests[name]
+			lower, hasLower := containerRecommendation.LowerBound[name]
+			upper, hasUpper := containerRecommendation.UpperBound[name]
+			if !ok || request.IsZero() || !hasLower || !hasUpper {
+				continue
+			}
+
+			toleratedLower := utils.ScaleQuantity(name, lower, 1-opts.lowerTolerance)
+			toleratedUpper := utils.ScaleQuantity(name, upper, 1+opts.upperTolerance)
+
+			var comparison string
+			switch utils.CompareRange(request, toleratedLower, toleratedUpper) {
+			case -1:
+				comparison = "below the lower bound"
+			case 1:
+				comparison = "above the upper bound"
+			default:
+				continue
+			}
+
+			target := containerRecommendation.Target[name]
+			messages = append(messages, fmt.Sprintf("container %s %s request %s is far %s of the goldilocks recommendation (target %s, lower bound %s, upper bound %s)",
+				c.Name, name, request.String(), comparison, target.String(), lower.String(), upper.String()))
+		}
+	}
+	return messages
+}
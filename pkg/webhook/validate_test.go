@@ -0,0 +1,108 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/fairwindsops/goldilocks/pkg/utils"
+)
+
+func deploymentAdmissionRequest(t *testing.T, cpu, memory string) *admissionv1.AdmissionRequest {
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{"cpu": resource.MustParse(cpu), "memory": resource.MustParse(memory)},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	raw, err := json.Marshal(deployment)
+	assert.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		UID:       "1234",
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Namespace: "testing",
+		Operation: admissionv1.Update,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func Test_validateDeployment(t *testing.T) {
+	tests := []struct {
+		name         string
+		nsLabels     map[string]string
+		cpu          string
+		memory       string
+		wantAllowed  bool
+		wantWarnings []string
+	}{
+		{
+			name:        "within tolerated bounds",
+			nsLabels:    map[string]string{},
+			cpu:         "400m",
+			memory:      "32Mi",
+			wantAllowed: true,
+		},
+		{
+			name:        "mis-sized warns",
+			nsLabels:    map[string]string{},
+			cpu:         "401m",
+			memory:      "31Mi",
+			wantAllowed: true,
+			wantWarnings: []string{
+				"container app cpu request 401m is far above the upper bound of the goldilocks recommendation (target 100m, lower bound 50m, upper bound 200m)",
+				"container app memory request 31Mi is far below the lower bound of the goldilocks recommendation (target 128Mi, lower bound 64Mi, upper bound 256Mi)",
+			},
+		},
+		{
+			name:        "mis-sized in an enforced namespace rejects",
+			nsLabels:    map[string]string{utils.EnforceRecommendationsLabel: "true"},
+			cpu:         "2",
+			memory:      "128Mi",
+			wantAllowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := setupWebhookForTests(t, tt.nsLabels)
+
+			got := opts.validateDeployment(deploymentAdmissionRequest(t, tt.cpu, tt.memory))
+			assert.Equal(t, tt.wantAllowed, got.Allowed)
+			assert.EqualValues(t, tt.wantWarnings, got.Warnings)
+			if !tt.wantAllowed {
+				assert.Contains(t, got.Result.Message, "container app cpu request 2 is far above the upper bound")
+			}
+		})
+	}
+}
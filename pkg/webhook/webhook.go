@@ -0,0 +1,111 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// admitFunc reviews a single AdmissionRequest and returns the response for it
+type admitFunc func(*admissionv1.AdmissionRequest) *admissionResponse
+
+// admissionResponse is an AdmissionResponse with warnings for the client,
+// which the API server supports since Kubernetes 1.19 and ignores before that
+type admissionResponse struct {
+	admissionv1.AdmissionResponse `json:",inline"`
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// admissionReview is an AdmissionReview that replies with an admissionResponse
+type admissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Request  *admissionv1.AdmissionRequest `json:"request,omitempty"`
+	Response *admissionResponse            `json:"response,omitempty"`
+}
+
+// GetRouter returns a mux router serving all routes necessary for the admission webhooks
+func GetRouter(setters ...Option) *mux.Router {
+	opts := defaultOptions()
+	for _, setter := range setters {
+		setter(opts)
+	}
+
+	router := mux.NewRouter()
+
+	// health
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	// admission
+	router.Handle("/mutate", serveAdmission(opts.mutatePod)).Methods(http.MethodPost)
+	router.Handle("/validate", serveAdmission(opts.validateDeployment)).Methods(http.MethodPost)
+
+	return router
+}
+
+// serveAdmission decodes an AdmissionReview from the request and replies with the reviewed response
+func serveAdmission(admit admitFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			klog.Errorf("Error reading admission request: %v", err)
+			http.Error(w, "Error reading admission request", http.StatusBadRequest)
+			return
+		}
+
+		review := admissionReview{}
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			klog.Errorf("Error decoding admission review: %v", err)
+			http.Error(w, "Error decoding admission review", http.StatusBadRequest)
+			return
+		}
+
+		response := admit(review.Request)
+		response.UID = review.Request.UID
+
+		reviewJSON, err := json.Marshal(admissionReview{
+			TypeMeta: review.TypeMeta,
+			Response: response,
+		})
+		if err != nil {
+			klog.Errorf("Error encoding admission review: %v", err)
+			http.Error(w, "Error encoding admission review", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(reviewJSON)
+		if err != nil {
+			klog.Errorf("Error writing admission review: %v", err)
+		}
+	})
+}
+
+// allowed returns a response admitting the request without any changes
+func allowed() *admissionResponse {
+	return &admissionResponse{
+		AdmissionResponse: admissionv1.AdmissionResponse{
+			Allowed: true,
+		},
+	}
+}